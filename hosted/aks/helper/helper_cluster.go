@@ -1,11 +1,12 @@
 package helper
 
 import (
+	"encoding/json"
 	"fmt"
 
-	"github.com/Masterminds/semver/v3"
 	"github.com/rancher/rancher/tests/framework/clients/rancher"
 	management "github.com/rancher/rancher/tests/framework/clients/rancher/generated/management/v3"
+	"github.com/rancher/rancher/tests/framework/extensions/clusters/aks"
 	"github.com/rancher/rancher/tests/framework/extensions/clusters/kubernetesversions"
 	"github.com/rancher/rancher/tests/framework/pkg/config"
 	namegen "github.com/rancher/rancher/tests/framework/pkg/namegenerator"
@@ -13,36 +14,35 @@ import (
 
 	"github.com/epinio/epinio/acceptance/helpers/proc"
 	"github.com/pkg/errors"
+	"github.com/valaparthvi/highlander-tests/hosted/helpers"
 )
 
+const (
+	// SystemMode is the mode of the nodepools hosting the critical system pods; a cluster must have at least one
+	SystemMode = "System"
+	// UserMode is the mode of the nodepools dedicated to the application pods
+	UserMode = "User"
+)
+
+// NodePoolLabel is the label AKS adds to every node with the name of the nodepool it belongs to
+const NodePoolLabel = "agentpool"
+
 // UpgradeClusterKubernetesVersion upgrades the k8s version to the value defined by upgradeToVersion.
 func UpgradeClusterKubernetesVersion(cluster *management.Cluster, upgradeToVersion *string, client *rancher.Client) (*management.Cluster, error) {
-	upgradedCluster := new(management.Cluster)
-	upgradedCluster.Name = cluster.Name
-	upgradedCluster.AKSConfig = cluster.AKSConfig
-	upgradedCluster.AKSConfig.KubernetesVersion = upgradeToVersion
-
-	cluster, err := client.Management.Cluster.Update(cluster, &upgradedCluster)
-	if err != nil {
-		return nil, err
-	}
-	return cluster, nil
+	return updateAKSConfig(cluster, client, func(aksConfig *management.AKSClusterConfigSpec) error {
+		aksConfig.KubernetesVersion = upgradeToVersion
+		return nil
+	})
 }
 
 // UpgradeNodeKubernetesVersion upgrades the k8s version of nodepool to the value defined by upgradeToVersion.
 func UpgradeNodeKubernetesVersion(cluster *management.Cluster, upgradeToVersion *string, client *rancher.Client) (*management.Cluster, error) {
-	upgradedCluster := new(management.Cluster)
-	upgradedCluster.Name = cluster.Name
-	upgradedCluster.AKSConfig = cluster.AKSConfig
-	for i := range upgradedCluster.AKSConfig.NodePools {
-		upgradedCluster.AKSConfig.NodePools[i].OrchestratorVersion = upgradeToVersion
-	}
-
-	cluster, err := client.Management.Cluster.Update(cluster, &upgradedCluster)
-	if err != nil {
-		return nil, err
-	}
-	return cluster, nil
+	return updateAKSConfig(cluster, client, func(aksConfig *management.AKSClusterConfigSpec) error {
+		for i := range aksConfig.NodePools {
+			aksConfig.NodePools[i].OrchestratorVersion = upgradeToVersion
+		}
+		return nil
+	})
 }
 
 // DeleteAKSHostCluster deletes the AKS cluster
@@ -57,74 +57,246 @@ func ListSingleVariantAKSAvailableVersions(client *rancher.Client, cloudCredenti
 	if err != nil {
 		return nil, err
 	}
-	var singleVersionList []string
-	var oldMinor uint64
-	for _, version := range availableVersions {
-		semVersion := semver.MustParse(version)
-		if currentMinor := semVersion.Minor(); oldMinor != currentMinor {
-			singleVersionList = append(singleVersionList, version)
-			oldMinor = currentMinor
-		}
-	}
-	return singleVersionList, nil
+	return helpers.SingleVariantVersions(availableVersions), nil
 }
 
 // AddNodePool adds a nodepool to the list
 func AddNodePool(cluster *management.Cluster, increaseBy int, client *rancher.Client) (*management.Cluster, error) {
-	upgradedCluster := new(management.Cluster)
-	upgradedCluster.Name = cluster.Name
-	upgradedCluster.AKSConfig = cluster.AKSConfig
 	nodeConfig := AksHostNodeConfig()
 
-	// TODO: Get Count from config file or function parameter
-	for i := 1; i <= increaseBy; i++ {
-		for _, np := range nodeConfig {
-			newNodepool := management.AKSNodePool{
-				Count:             pointer.Int64(1),
-				VMSize:            np.VMSize,
-				Mode:              np.Mode,
-				EnableAutoScaling: np.EnableAutoScaling,
-				Name:              pointer.String(namegen.RandStringLower(5)),
+	return updateAKSConfig(cluster, client, func(aksConfig *management.AKSClusterConfigSpec) error {
+		// TODO: Get Count from config file or function parameter
+		for i := 1; i <= increaseBy; i++ {
+			for _, np := range nodeConfig {
+				newNodepool := management.AKSNodePool{
+					Count:             pointer.Int64(1),
+					VMSize:            np.VMSize,
+					Mode:              np.Mode,
+					EnableAutoScaling: np.EnableAutoScaling,
+					Name:              pointer.String(namegen.RandStringLower(5)),
+				}
+				aksConfig.NodePools = append(aksConfig.NodePools, newNodepool)
 			}
-			upgradedCluster.AKSConfig.NodePools = append(upgradedCluster.AKSConfig.NodePools, newNodepool)
 		}
-	}
-	cluster, err := client.Management.Cluster.Update(cluster, &upgradedCluster)
-	if err != nil {
-		return nil, err
-	}
-	return cluster, nil
+		return nil
+	})
+}
+
+// AddWindowsNodePool adds a Windows nodepool in User mode; the cluster must use the azure network plugin
+func AddWindowsNodePool(cluster *management.Cluster, client *rancher.Client) (*management.Cluster, error) {
+	nodeConfig := AksHostNodeConfig()
+
+	return updateAKSConfig(cluster, client, func(aksConfig *management.AKSClusterConfigSpec) error {
+		// Windows nodepool names are limited to 6 characters
+		newNodepool := management.AKSNodePool{
+			Count:  pointer.Int64(1),
+			VMSize: nodeConfig[0].VMSize,
+			Mode:   UserMode,
+			OsType: "Windows",
+			Name:   pointer.String(namegen.RandStringLower(5)),
+		}
+		aksConfig.NodePools = append(aksConfig.NodePools, newNodepool)
+		return nil
+	})
+}
+
+// AddNodePoolWithMode adds a nodepool named nodePoolName of a single node in mode, i.e. System or User; the VM size is taken from the config
+func AddNodePoolWithMode(cluster *management.Cluster, client *rancher.Client, nodePoolName, mode string) (*management.Cluster, error) {
+	nodeConfig := AksHostNodeConfig()
+
+	return updateAKSConfig(cluster, client, func(aksConfig *management.AKSClusterConfigSpec) error {
+		newNodepool := management.AKSNodePool{
+			Count:  pointer.Int64(1),
+			VMSize: nodeConfig[0].VMSize,
+			Mode:   mode,
+			Name:   pointer.String(nodePoolName),
+		}
+		aksConfig.NodePools = append(aksConfig.NodePools, newNodepool)
+		return nil
+	})
 }
 
 // DeleteNodePool deletes a nodepool from the list
 // TODO: Modify this method to delete a custom qty of DeleteNodePool, perhaps by adding an `decreaseBy int` arg
 func DeleteNodePool(cluster *management.Cluster, client *rancher.Client) (*management.Cluster, error) {
-	upgradedCluster := new(management.Cluster)
-	upgradedCluster.Name = cluster.Name
-	upgradedCluster.AKSConfig = cluster.AKSConfig
-	upgradedCluster.AKSConfig.NodePools = cluster.AKSConfig.NodePools[:1]
+	return updateAKSConfig(cluster, client, func(aksConfig *management.AKSClusterConfigSpec) error {
+		aksConfig.NodePools = aksConfig.NodePools[:1]
+		return nil
+	})
+}
 
-	cluster, err := client.Management.Cluster.Update(cluster, &upgradedCluster)
+// DeleteNodePoolByName deletes the nodepool named nodePoolName from the list
+func DeleteNodePoolByName(cluster *management.Cluster, client *rancher.Client, nodePoolName string) (*management.Cluster, error) {
+	return updateAKSConfig(cluster, client, func(aksConfig *management.AKSClusterConfigSpec) error {
+		i, err := nodePoolIndex(aksConfig, nodePoolName)
+		if err != nil {
+			return err
+		}
+		aksConfig.NodePools = append(aksConfig.NodePools[:i], aksConfig.NodePools[i+1:]...)
+		return nil
+	})
+}
+
+// ScaleNodePool modifies the number of initialNodeCount of all the nodepools as defined by nodeCount
+func ScaleNodePool(cluster *management.Cluster, client *rancher.Client, nodeCount int64) (*management.Cluster, error) {
+	return updateAKSConfig(cluster, client, func(aksConfig *management.AKSClusterConfigSpec) error {
+		for i := range aksConfig.NodePools {
+			aksConfig.NodePools[i].Count = pointer.Int64(nodeCount)
+		}
+		return nil
+	})
+}
+
+// ScaleNodePoolByName modifies the number of nodes of the nodepool named nodePoolName as defined by nodeCount;
+// only User nodepools can be scaled to zero
+func ScaleNodePoolByName(cluster *management.Cluster, client *rancher.Client, nodePoolName string, nodeCount int64) (*management.Cluster, error) {
+	return updateNodePool(cluster, client, nodePoolName, func(np *management.AKSNodePool) error {
+		np.Count = pointer.Int64(nodeCount)
+		return nil
+	})
+}
+
+// UpdateNodePoolMode sets the mode of the nodepool named nodePoolName to System or User;
+// AKS requires at least one System nodepool, and a System nodepool to have at least one node
+func UpdateNodePoolMode(cluster *management.Cluster, client *rancher.Client, nodePoolName, mode string) (*management.Cluster, error) {
+	return updateNodePool(cluster, client, nodePoolName, func(np *management.AKSNodePool) error {
+		np.Mode = mode
+		return nil
+	})
+}
+
+// UpdateNodePoolLabels sets the kubernetes node labels of the nodepool named nodePoolName to the value defined by labels;
+// an empty map removes all the labels previously added to the nodepool
+func UpdateNodePoolLabels(cluster *management.Cluster, client *rancher.Client, nodePoolName string, labels map[string]string) (*management.Cluster, error) {
+	return updateNodePool(cluster, client, nodePoolName, func(np *management.AKSNodePool) error {
+		np.NodeLabels = labels
+		return nil
+	})
+}
+
+// UpdateNodePoolTaints sets the kubernetes node taints of the nodepool named nodePoolName to the value defined by taints;
+// taints are in the `key=value:Effect` format accepted by AKS, and an empty list removes all the taints previously added to the nodepool
+func UpdateNodePoolTaints(cluster *management.Cluster, client *rancher.Client, nodePoolName string, taints []string) (*management.Cluster, error) {
+	return updateNodePool(cluster, client, nodePoolName, func(np *management.AKSNodePool) error {
+		np.NodeTaints = taints
+		return nil
+	})
+}
+
+// UpdateClusterTags sets the Azure resource tags of the cluster to the value defined by tags;
+// an empty map removes all the tags previously added to the cluster
+func UpdateClusterTags(cluster *management.Cluster, client *rancher.Client, tags map[string]string) (*management.Cluster, error) {
+	return updateAKSConfig(cluster, client, func(aksConfig *management.AKSClusterConfigSpec) error {
+		aksConfig.Tags = tags
+		return nil
+	})
+}
+
+// AdoptUpstreamSpec waits for the upstream spec of the imported cluster to be populated and adopts it into the AKS config, keeping the identity and credential of the cluster,
+// so that day-2 operations start from the nodepools that exist on the cloud provider; it then verifies that the nodepools of the config and the upstream spec agree.
+// It is a workaround till https://github.com/rancher/aks-operator/issues/251 is fixed; once the config is populated on import, the fix is logged and the config is left as is.
+// On failure, the last known state of the cluster is returned along with the error, so that callers can still clean it up
+func AdoptUpstreamSpec(cluster *management.Cluster, client *rancher.Client) (*management.Cluster, error) {
+	upstreamCluster, err := helpers.WaitForUpstreamSpec(client, cluster.ID)
 	if err != nil {
-		return nil, err
+		return cluster, err
+	}
+	cluster = upstreamCluster
+
+	if len(cluster.AKSConfig.NodePools) > 0 {
+		fmt.Println("AKS config of the imported cluster is already populated, the upstream spec workaround is no longer needed: ", cluster.Name)
+	} else {
+		updatedCluster, err := helpers.UpdateCluster(client, cluster.ID, func(upgradedCluster *management.Cluster) error {
+			if upgradedCluster.AKSStatus == nil || upgradedCluster.AKSStatus.UpstreamSpec == nil {
+				return errors.Errorf("upstream spec of cluster %s is not populated", upgradedCluster.Name)
+			}
+			aksConfig := upgradedCluster.AKSStatus.UpstreamSpec
+			aksConfig.AzureCredentialSecret = upgradedCluster.AKSConfig.AzureCredentialSecret
+			aksConfig.ClusterName = upgradedCluster.AKSConfig.ClusterName
+			aksConfig.Imported = upgradedCluster.AKSConfig.Imported
+			aksConfig.ResourceGroup = upgradedCluster.AKSConfig.ResourceGroup
+			aksConfig.ResourceLocation = upgradedCluster.AKSConfig.ResourceLocation
+			upgradedCluster.AKSConfig = aksConfig
+			return nil
+		})
+		if err != nil {
+			return cluster, err
+		}
+		cluster = updatedCluster
+		fmt.Println("Adopted the upstream spec into the AKS config of the imported cluster: ", cluster.Name)
+	}
+
+	diff, err := helpers.DiffConfigWithUpstreamSpec(cluster, "nodePools")
+	if err != nil {
+		return cluster, err
+	}
+	if len(diff) > 0 {
+		return cluster, errors.Errorf("nodepools of the AKS config and the upstream spec of cluster %s do not agree: %v", cluster.Name, diff)
 	}
 	return cluster, nil
 }
 
-// ScaleNodePool modifies the number of initialNodeCount of all the nodepools as defined by nodeCount
-func ScaleNodePool(cluster *management.Cluster, client *rancher.Client, nodeCount int64) (*management.Cluster, error) {
-	upgradedCluster := new(management.Cluster)
-	upgradedCluster.Name = cluster.Name
-	upgradedCluster.AKSConfig = cluster.AKSConfig
-	for i := range upgradedCluster.AKSConfig.NodePools {
-		upgradedCluster.AKSConfig.NodePools[i].Count = pointer.Int64(nodeCount)
+// updateAKSConfig applies updateFunc to a copy of the latest AKS config of the cluster and updates the cluster with it
+func updateAKSConfig(cluster *management.Cluster, client *rancher.Client, updateFunc func(*management.AKSClusterConfigSpec) error) (*management.Cluster, error) {
+	return helpers.UpdateCluster(client, cluster.ID, func(upgradedCluster *management.Cluster) error {
+		return updateFunc(upgradedCluster.AKSConfig)
+	})
+}
+
+// updateNodePool applies updateFunc to the nodepool named nodePoolName in the latest AKS config of the cluster and updates the cluster with it
+func updateNodePool(cluster *management.Cluster, client *rancher.Client, nodePoolName string, updateFunc func(*management.AKSNodePool) error) (*management.Cluster, error) {
+	return updateAKSConfig(cluster, client, func(aksConfig *management.AKSClusterConfigSpec) error {
+		i, err := nodePoolIndex(aksConfig, nodePoolName)
+		if err != nil {
+			return err
+		}
+		return updateFunc(&aksConfig.NodePools[i])
+	})
+}
+
+// ScaleNodePoolFields are the config and upstream spec fields changed by scaling, i.e. the count of the nodepools
+var ScaleNodePoolFields = []string{"nodePools[*].count"}
+
+// NodeCountPerPool returns the number of nodes configured for each nodepool of the cluster
+func NodeCountPerPool(cluster *management.Cluster) map[string]int64 {
+	counts := map[string]int64{}
+	for _, np := range cluster.AKSConfig.NodePools {
+		counts[*np.Name] = *np.Count
 	}
+	return counts
+}
 
-	cluster, err := client.Management.Cluster.Update(cluster, &upgradedCluster)
+// UpstreamNodePool returns the nodepool named nodePoolName from the upstream spec of the cluster, i.e. as reported by AKS to Rancher
+func UpstreamNodePool(cluster *management.Cluster, nodePoolName string) (*management.AKSNodePool, error) {
+	if cluster.AKSStatus == nil || cluster.AKSStatus.UpstreamSpec == nil {
+		return nil, errors.Errorf("upstream spec of cluster %s is not populated", cluster.Name)
+	}
+	i, err := nodePoolIndex(cluster.AKSStatus.UpstreamSpec, nodePoolName)
 	if err != nil {
 		return nil, err
 	}
-	return cluster, nil
+	return &cluster.AKSStatus.UpstreamSpec.NodePools[i], nil
+}
+
+// SystemNodePoolNames returns the names of the nodepools in System mode, for e.g. those of the AKS config of a cluster or of AksHostNodeConfig
+func SystemNodePoolNames(nodePools []management.AKSNodePool) []string {
+	var names []string
+	for _, np := range nodePools {
+		if np.Mode == SystemMode {
+			names = append(names, *np.Name)
+		}
+	}
+	return names
+}
+
+// nodePoolIndex returns the index of the nodepool named nodePoolName in the AKS config
+func nodePoolIndex(aksConfig *management.AKSClusterConfigSpec, nodePoolName string) (int, error) {
+	for i, np := range aksConfig.NodePools {
+		if np.Name != nil && *np.Name == nodePoolName {
+			return i, nil
+		}
+	}
+	return -1, errors.Errorf("nodepool %s not found in cluster %s", nodePoolName, aksConfig.ClusterName)
 }
 
 // ListAKSAvailableVersions is a function to list and return only available AKS versions for a specific cluster.
@@ -157,6 +329,52 @@ func CreateAKSClusterOnAzure(location string, clusterName string, k8sVersion str
 	return nil
 }
 
+// GetTagsOnAzure returns the tags of the AKS cluster using AZ CLI
+func GetTagsOnAzure(resourceGroup, clusterName string) (map[string]string, error) {
+	out, err := proc.RunW("az", "aks", "show", "--resource-group", resourceGroup, "--name", clusterName, "--query", "tags", "--output", "json")
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to show cluster: "+out)
+	}
+
+	tags := map[string]string{}
+	// az prints null if the cluster has no tags, which leaves the map empty
+	if err = json.Unmarshal([]byte(out), &tags); err != nil {
+		return nil, errors.Wrap(err, "Failed to parse cluster tags: "+out)
+	}
+	return tags, nil
+}
+
+// AzureNodePool is the subset of the nodepool details reported by `az aks nodepool show` the specs look at
+type AzureNodePool struct {
+	Name              string `json:"name"`
+	Mode              string `json:"mode"`
+	Count             int64  `json:"count"`
+	ProvisioningState string `json:"provisioningState"`
+}
+
+// GetNodePoolOnAzure returns the nodepool named nodePoolName of the AKS cluster using AZ CLI
+func GetNodePoolOnAzure(resourceGroup, clusterName, nodePoolName string) (*AzureNodePool, error) {
+	out, err := proc.RunW("az", "aks", "nodepool", "show", "--resource-group", resourceGroup, "--cluster-name", clusterName, "--name", nodePoolName, "--output", "json")
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to show nodepool: "+out)
+	}
+
+	nodePool := &AzureNodePool{}
+	if err = json.Unmarshal([]byte(out), nodePool); err != nil {
+		return nil, errors.Wrap(err, "Failed to parse nodepool: "+out)
+	}
+	return nodePool, nil
+}
+
+// GetKubeconfigOnAzure writes the kubeconfig of the AKS cluster to kubeconfigPath using AZ CLI
+func GetKubeconfigOnAzure(resourceGroup, clusterName, kubeconfigPath string) error {
+	out, err := proc.RunW("az", "aks", "get-credentials", "--resource-group", resourceGroup, "--name", clusterName, "--file", kubeconfigPath, "--overwrite-existing")
+	if err != nil {
+		return errors.Wrap(err, "Failed to get cluster credentials: "+out)
+	}
+	return nil
+}
+
 // Complete cleanup steps for Azure AKS
 func DeleteAKSClusteronAzure(clusterName string) error {
 
@@ -171,6 +389,30 @@ func DeleteAKSClusteronAzure(clusterName string) error {
 	return nil
 }
 
+// CreateAKSHostedClusterWithConfig creates an AKS hosted cluster from the aksClusterConfig after applying updateFunc to the AKS config spec;
+// it allows setting fields that aks.CreateAKSHostedCluster does not read from the config, such as the network policy or the outbound type
+func CreateAKSHostedClusterWithConfig(client *rancher.Client, displayName, cloudCredentialID string, windowsPreferedCluster bool, updateFunc func(*management.AKSClusterConfigSpec)) (*management.Cluster, error) {
+	aksHostCluster := aks.HostClusterConfig(displayName, cloudCredentialID)
+	updateFunc(aksHostCluster)
+	enableNetworkPolicy := false
+	cluster := &management.Cluster{
+		AKSConfig:               aksHostCluster,
+		DockerRootDir:           "/var/lib/docker",
+		EnableClusterAlerting:   false,
+		EnableClusterMonitoring: false,
+		EnableNetworkPolicy:     &enableNetworkPolicy,
+		Labels:                  map[string]string{},
+		Name:                    displayName,
+		WindowsPreferedCluster:  windowsPreferedCluster,
+	}
+
+	clusterResp, err := client.Management.Cluster.Create(cluster)
+	if err != nil {
+		return nil, err
+	}
+	return clusterResp, err
+}
+
 func ImportAKSHostedCluster(client *rancher.Client, displayName, cloudCredentialID string, enableClusterAlerting, enableClusterMonitoring, enableNetworkPolicy, windowsPreferedCluster bool, labels map[string]string) (*management.Cluster, error) {
 	aksHostCluster := AksHostClusterConfig(displayName, cloudCredentialID)
 	cluster := &management.Cluster{
@@ -217,3 +459,15 @@ type ImportClusterConfig struct {
 	Imported         bool                      `json:"imported" yaml:"imported"`
 	NodePools        []*management.AKSNodePool `json:"nodePools" yaml:"nodePools"`
 }
+
+// NetworkingConfig is the configuration of the pre-existing virtual network used to provision a cluster with bring-your-own networking
+type NetworkingConfig struct {
+	VirtualNetwork              string `json:"virtualNetwork" yaml:"virtualNetwork"`
+	VirtualNetworkResourceGroup string `json:"virtualNetworkResourceGroup" yaml:"virtualNetworkResourceGroup"`
+	Subnet                      string `json:"subnet" yaml:"subnet"`
+	ServiceCIDR                 string `json:"serviceCidr" yaml:"serviceCidr"`
+	DNSServiceIP                string `json:"dnsServiceIp" yaml:"dnsServiceIp"`
+}
+
+// NetworkingConfigConfigurationFileKey is the json/yaml config key for NetworkingConfig
+const NetworkingConfigConfigurationFileKey = "aksNetworkingConfig"
@@ -44,8 +44,8 @@ var _ = Describe("P0Importing", func() {
 			Expect(err).To(BeNil())
 			cluster, err = helpers.WaitUntilClusterIsReady(cluster, ctx.RancherClient)
 			Expect(err).To(BeNil())
-			// Workaround to add new Nodegroup till https://github.com/rancher/aks-operator/issues/251 is fixed
-			cluster.AKSConfig = cluster.AKSStatus.UpstreamSpec
+			cluster, err = helper.AdoptUpstreamSpec(cluster, ctx.RancherClient)
+			Expect(err).To(BeNil())
 		})
 		AfterEach(func() {
 			err := helper.DeleteAKSHostCluster(cluster, ctx.RancherClient)
@@ -75,26 +75,45 @@ var _ = Describe("P0Importing", func() {
 				Expect(podErrors).To(BeEmpty())
 			})
 
+			By("checking the cluster directly through its kubeconfig", func() {
+				err := helpers.VerifyClusterThroughKubeconfig(ctx.RancherClient, cluster.ID, *cluster.AKSConfig.KubernetesVersion)
+				Expect(err).To(BeNil())
+			})
+
 			currentNodePoolNumber := len(cluster.AKSConfig.NodePools)
 			initialNodeCount := *cluster.AKSConfig.NodePools[0].Count
 
 			By("scaling up the nodepool", func() {
-				var err error
+				snapshot, err := helpers.TakeClusterSnapshot(ctx.RancherClient, cluster.ID)
+				Expect(err).To(BeNil())
 				cluster, err = helper.ScaleNodePool(cluster, ctx.RancherClient, initialNodeCount+1)
 				Expect(err).To(BeNil())
-				err = clusters.WaitClusterToBeUpgraded(ctx.RancherClient, cluster.ID)
+				cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+				Expect(err).To(BeNil())
+				err = helpers.WaitForNodeCountPerPool(ctx.RancherClient, cluster.ID, helper.NodePoolLabel, helper.NodeCountPerPool(cluster), true)
 				Expect(err).To(BeNil())
+				diff, err := helpers.DiffClusterSince(ctx.RancherClient, cluster.ID, snapshot)
+				Expect(err).To(BeNil())
+				Expect(diff.Config.Except(helper.ScaleNodePoolFields...)).To(BeEmpty())
+				Expect(diff.UpstreamSpec.Except(helper.ScaleNodePoolFields...)).To(BeEmpty())
 				for i := range cluster.AKSConfig.NodePools {
 					Expect(*cluster.AKSConfig.NodePools[i].Count).To(BeNumerically("==", initialNodeCount+1))
 				}
 			})
 
 			By("scaling down the nodepool", func() {
-				var err error
+				snapshot, err := helpers.TakeClusterSnapshot(ctx.RancherClient, cluster.ID)
+				Expect(err).To(BeNil())
 				cluster, err = helper.ScaleNodePool(cluster, ctx.RancherClient, initialNodeCount)
 				Expect(err).To(BeNil())
-				err = clusters.WaitClusterToBeUpgraded(ctx.RancherClient, cluster.ID)
+				cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+				Expect(err).To(BeNil())
+				err = helpers.WaitForNodeCountPerPool(ctx.RancherClient, cluster.ID, helper.NodePoolLabel, helper.NodeCountPerPool(cluster), true)
 				Expect(err).To(BeNil())
+				diff, err := helpers.DiffClusterSince(ctx.RancherClient, cluster.ID, snapshot)
+				Expect(err).To(BeNil())
+				Expect(diff.Config.Except(helper.ScaleNodePoolFields...)).To(BeEmpty())
+				Expect(diff.UpstreamSpec.Except(helper.ScaleNodePoolFields...)).To(BeEmpty())
 				for i := range cluster.AKSConfig.NodePools {
 					Expect(*cluster.AKSConfig.NodePools[i].Count).To(BeNumerically("==", initialNodeCount))
 				}
@@ -104,7 +123,7 @@ var _ = Describe("P0Importing", func() {
 				var err error
 				cluster, err = helper.AddNodePool(cluster, increaseBy, ctx.RancherClient)
 				Expect(err).To(BeNil())
-				err = clusters.WaitClusterToBeUpgraded(ctx.RancherClient, cluster.ID)
+				cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
 				Expect(err).To(BeNil())
 				Expect(len(cluster.AKSConfig.NodePools)).To(BeNumerically("==", currentNodePoolNumber+1))
 			})
@@ -112,7 +131,7 @@ var _ = Describe("P0Importing", func() {
 				var err error
 				cluster, err = helper.DeleteNodePool(cluster, ctx.RancherClient)
 				Expect(err).To(BeNil())
-				err = clusters.WaitClusterToBeUpgraded(ctx.RancherClient, cluster.ID)
+				cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
 				Expect(err).To(BeNil())
 				Expect(len(cluster.AKSConfig.NodePools)).To(BeNumerically("==", currentNodePoolNumber))
 			})
@@ -133,7 +152,9 @@ var _ = Describe("P0Importing", func() {
 					var err error
 					cluster, err = helper.UpgradeClusterKubernetesVersion(cluster, upgradeToVersion, ctx.RancherClient)
 					Expect(err).To(BeNil())
-					cluster, err = helpers.WaitUntilClusterIsReady(cluster, ctx.RancherClient)
+					cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+					Expect(err).To(BeNil())
+					_, err = helpers.WaitForClusterGitVersion(ctx.RancherClient, cluster.ID, *upgradeToVersion)
 					Expect(err).To(BeNil())
 					Expect(cluster.AKSConfig.KubernetesVersion).To(BeEquivalentTo(upgradeToVersion))
 					for _, np := range cluster.AKSConfig.NodePools {
@@ -145,7 +166,9 @@ var _ = Describe("P0Importing", func() {
 					var err error
 					cluster, err = helper.UpgradeNodeKubernetesVersion(cluster, upgradeToVersion, ctx.RancherClient)
 					Expect(err).To(BeNil())
-					err = clusters.WaitClusterToBeUpgraded(ctx.RancherClient, cluster.ID)
+					cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+					Expect(err).To(BeNil())
+					err = helpers.WaitForNodePoolsKubeletVersion(ctx.RancherClient, cluster.ID, helper.NodePoolLabel, *upgradeToVersion)
 					Expect(err).To(BeNil())
 					Expect(cluster.AKSConfig.KubernetesVersion).To(BeEquivalentTo(upgradeToVersion))
 					for _, np := range cluster.AKSConfig.NodePools {
@@ -5,6 +5,8 @@ import (
 	. "github.com/onsi/gomega"
 	nodestat "github.com/rancher/rancher/tests/framework/extensions/nodes"
 	"github.com/rancher/rancher/tests/framework/extensions/workloads/pods"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
 
 	management "github.com/rancher/rancher/tests/framework/clients/rancher/generated/management/v3"
 	"github.com/rancher/rancher/tests/framework/extensions/clusters"
@@ -40,14 +42,15 @@ var _ = Describe("P0Provisioning", func() {
 			})
 			cluster, err = aks.CreateAKSHostedCluster(ctx.RancherClient, clusterName, ctx.CloudCred.ID, false, false, false, false, map[string]string{})
 			Expect(err).To(BeNil())
+			// deleted by a cleanup so that it outlives the volume cleanups of the specs
+			DeferCleanup(func() {
+				// TODO: Delete Resource group also from AKS
+				err := helper.DeleteAKSHostCluster(cluster, ctx.RancherClient)
+				Expect(err).To(BeNil())
+			})
 			cluster, err = helpers.WaitUntilClusterIsReady(cluster, ctx.RancherClient)
 			Expect(err).To(BeNil())
 		})
-		AfterEach(func() {
-			// TODO: Delete Resource group also from AKS
-			err := helper.DeleteAKSHostCluster(cluster, ctx.RancherClient)
-			Expect(err).To(BeNil())
-		})
 		It("should successfully provision the cluster", func() {
 
 			By("checking cluster name is same", func() {
@@ -70,6 +73,16 @@ var _ = Describe("P0Provisioning", func() {
 				Expect(podErrors).To(BeEmpty())
 			})
 
+			By("checking the cluster directly through its kubeconfig", func() {
+				err := helpers.VerifyClusterThroughKubeconfig(ctx.RancherClient, cluster.ID, *cluster.AKSConfig.KubernetesVersion)
+				Expect(err).To(BeNil())
+			})
+
+			By("checking a LoadBalancer service is reachable", func() {
+				err := helpers.VerifyLoadBalancerService(ctx.RancherClient, cluster.ID)
+				Expect(err).To(BeNil())
+			})
+
 		})
 
 		Context("Upgrading K8s version", func() {
@@ -83,11 +96,18 @@ var _ = Describe("P0Provisioning", func() {
 			})
 
 			It("should be able to upgrade k8s version of the cluster", func() {
+				var (
+					clientset  *kubernetes.Clientset
+					volumeData *helpers.VolumeData
+				)
+
 				By("upgrading the ControlPlane", func() {
 					var err error
 					cluster, err = helper.UpgradeClusterKubernetesVersion(cluster, upgradeToVersion, ctx.RancherClient)
 					Expect(err).To(BeNil())
-					cluster, err = helpers.WaitUntilClusterIsReady(cluster, ctx.RancherClient)
+					cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+					Expect(err).To(BeNil())
+					_, err = helpers.WaitForClusterGitVersion(ctx.RancherClient, cluster.ID, *upgradeToVersion)
 					Expect(err).To(BeNil())
 					Expect(cluster.AKSConfig.KubernetesVersion).To(BeEquivalentTo(upgradeToVersion))
 					for _, np := range cluster.AKSConfig.NodePools {
@@ -95,17 +115,40 @@ var _ = Describe("P0Provisioning", func() {
 					}
 				})
 
+				By("writing data to a persistent volume", func() {
+					var err error
+					clientset, err = helpers.GetDownstreamClientset(ctx.RancherClient, cluster.ID)
+					Expect(err).To(BeNil())
+					volumeData, err = helpers.WriteVolumeData(clientset)
+					Expect(err).To(BeNil())
+					DeferCleanup(helpers.DeleteVolumeData, clientset, volumeData)
+				})
+
 				By("upgrading the NodePools", func() {
 					var err error
 					cluster, err = helper.UpgradeNodeKubernetesVersion(cluster, upgradeToVersion, ctx.RancherClient)
 					Expect(err).To(BeNil())
-					err = clusters.WaitClusterToBeUpgraded(ctx.RancherClient, cluster.ID)
+					cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+					Expect(err).To(BeNil())
+					err = helpers.WaitForNodePoolsKubeletVersion(ctx.RancherClient, cluster.ID, helper.NodePoolLabel, *upgradeToVersion)
 					Expect(err).To(BeNil())
 					Expect(cluster.AKSConfig.KubernetesVersion).To(BeEquivalentTo(upgradeToVersion))
 					for _, np := range cluster.AKSConfig.NodePools {
 						Expect(np.OrchestratorVersion).To(BeEquivalentTo(upgradeToVersion))
 					}
 				})
+
+				By("verifying the persistent volume data from a rescheduled pod", func() {
+					reader, err := helpers.VerifyVolumeData(clientset, volumeData)
+					Expect(err).To(BeNil())
+					// the upgrade reimages every node of the nodepools, so the volume must have moved to a new node or a new boot of its node
+					Expect(reader).ToNot(Equal(volumeData.Writer))
+				})
+
+				By("checking a LoadBalancer service is reachable", func() {
+					err := helpers.VerifyLoadBalancerService(ctx.RancherClient, cluster.ID)
+					Expect(err).To(BeNil())
+				})
 			})
 		})
 
@@ -116,7 +159,7 @@ var _ = Describe("P0Provisioning", func() {
 				var err error
 				cluster, err = helper.AddNodePool(cluster, increaseBy, ctx.RancherClient)
 				Expect(err).To(BeNil())
-				err = clusters.WaitClusterToBeUpgraded(ctx.RancherClient, cluster.ID)
+				cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
 				Expect(err).To(BeNil())
 				Expect(len(cluster.AKSConfig.NodePools)).To(BeNumerically("==", currentNodePoolNumber+1))
 			})
@@ -124,7 +167,7 @@ var _ = Describe("P0Provisioning", func() {
 				var err error
 				cluster, err = helper.DeleteNodePool(cluster, ctx.RancherClient)
 				Expect(err).To(BeNil())
-				err = clusters.WaitClusterToBeUpgraded(ctx.RancherClient, cluster.ID)
+				cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
 				Expect(err).To(BeNil())
 				Expect(len(cluster.AKSConfig.NodePools)).To(BeNumerically("==", currentNodePoolNumber))
 
@@ -132,30 +175,193 @@ var _ = Describe("P0Provisioning", func() {
 
 		})
 
+		It("should be possible to add, update and remove the nodepool labels and taints", func() {
+			var nodePoolName string
+			taint := corev1.Taint{Key: "highlander", Value: "true", Effect: corev1.TaintEffectNoSchedule}
+
+			By("adding a nodepool", func() {
+				var err error
+				cluster, err = helper.AddNodePool(cluster, increaseBy, ctx.RancherClient)
+				Expect(err).To(BeNil())
+				cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+				Expect(err).To(BeNil())
+				nodePoolName = *cluster.AKSConfig.NodePools[len(cluster.AKSConfig.NodePools)-1].Name
+			})
+
+			By("adding labels and taints to the nodepool", func() {
+				var err error
+				cluster, err = helper.UpdateNodePoolLabels(cluster, ctx.RancherClient, nodePoolName, map[string]string{"highlander": "add"})
+				Expect(err).To(BeNil())
+				cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+				Expect(err).To(BeNil())
+				cluster, err = helper.UpdateNodePoolTaints(cluster, ctx.RancherClient, nodePoolName, []string{"highlander=true:NoSchedule"})
+				Expect(err).To(BeNil())
+				cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+				Expect(err).To(BeNil())
+
+				nodes, err := helpers.ListDownstreamNodes(ctx.RancherClient, cluster.ID, helper.NodePoolLabel+"="+nodePoolName)
+				Expect(err).To(BeNil())
+				Expect(nodes).ToNot(BeEmpty())
+				for _, node := range nodes {
+					Expect(node.Labels).To(HaveKeyWithValue("highlander", "add"))
+					Expect(node.Spec.Taints).To(ContainElement(taint))
+				}
+			})
+
+			By("updating the nodepool labels", func() {
+				var err error
+				cluster, err = helper.UpdateNodePoolLabels(cluster, ctx.RancherClient, nodePoolName, map[string]string{"highlander": "update"})
+				Expect(err).To(BeNil())
+				cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+				Expect(err).To(BeNil())
+
+				nodes, err := helpers.ListDownstreamNodes(ctx.RancherClient, cluster.ID, helper.NodePoolLabel+"="+nodePoolName)
+				Expect(err).To(BeNil())
+				Expect(nodes).ToNot(BeEmpty())
+				for _, node := range nodes {
+					Expect(node.Labels).To(HaveKeyWithValue("highlander", "update"))
+				}
+			})
+
+			By("removing the nodepool labels and taints", func() {
+				var err error
+				cluster, err = helper.UpdateNodePoolLabels(cluster, ctx.RancherClient, nodePoolName, map[string]string{})
+				Expect(err).To(BeNil())
+				cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+				Expect(err).To(BeNil())
+				cluster, err = helper.UpdateNodePoolTaints(cluster, ctx.RancherClient, nodePoolName, []string{})
+				Expect(err).To(BeNil())
+				cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+				Expect(err).To(BeNil())
+
+				nodes, err := helpers.ListDownstreamNodes(ctx.RancherClient, cluster.ID, helper.NodePoolLabel+"="+nodePoolName)
+				Expect(err).To(BeNil())
+				Expect(nodes).ToNot(BeEmpty())
+				for _, node := range nodes {
+					Expect(node.Labels).ToNot(HaveKey("highlander"))
+					Expect(node.Spec.Taints).ToNot(ContainElement(taint))
+				}
+			})
+		})
+
+		It("should be possible to add, update and remove the cluster labels and tags", func() {
+			resourceGroup := cluster.AKSConfig.ResourceGroup
+
+			By("adding cluster labels and tags", func() {
+				var err error
+				cluster, err = helpers.UpdateClusterLabels(cluster, ctx.RancherClient, map[string]string{"highlander": "add"}, nil)
+				Expect(err).To(BeNil())
+				cluster, err = helper.UpdateClusterTags(cluster, ctx.RancherClient, map[string]string{"highlander": "add"})
+				Expect(err).To(BeNil())
+				cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+				Expect(err).To(BeNil())
+				Expect(cluster.Labels).To(HaveKeyWithValue("highlander", "add"))
+
+				tags, err := helper.GetTagsOnAzure(resourceGroup, clusterName)
+				Expect(err).To(BeNil())
+				Expect(tags).To(HaveKeyWithValue("highlander", "add"))
+			})
+
+			By("updating cluster labels and tags", func() {
+				var err error
+				cluster, err = helpers.UpdateClusterLabels(cluster, ctx.RancherClient, map[string]string{"highlander": "update"}, nil)
+				Expect(err).To(BeNil())
+				cluster, err = helper.UpdateClusterTags(cluster, ctx.RancherClient, map[string]string{"highlander": "update"})
+				Expect(err).To(BeNil())
+				cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+				Expect(err).To(BeNil())
+				Expect(cluster.Labels).To(HaveKeyWithValue("highlander", "update"))
+
+				tags, err := helper.GetTagsOnAzure(resourceGroup, clusterName)
+				Expect(err).To(BeNil())
+				Expect(tags).To(HaveKeyWithValue("highlander", "update"))
+			})
+
+			By("removing cluster labels and tags", func() {
+				var err error
+				cluster, err = helpers.UpdateClusterLabels(cluster, ctx.RancherClient, nil, []string{"highlander"})
+				Expect(err).To(BeNil())
+				cluster, err = helper.UpdateClusterTags(cluster, ctx.RancherClient, map[string]string{})
+				Expect(err).To(BeNil())
+				cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+				Expect(err).To(BeNil())
+				Expect(cluster.Labels).ToNot(HaveKey("highlander"))
+
+				tags, err := helper.GetTagsOnAzure(resourceGroup, clusterName)
+				Expect(err).To(BeNil())
+				Expect(tags).ToNot(HaveKey("highlander"))
+			})
+		})
+
+		It("should be possible to install, upgrade and uninstall a catalog app", func() {
+			helpers.VerifyCatalogAppLifecycle(ctx.RancherClient, cluster.ID)
+		})
+
 		It("should be possible to scale up/down the nodepool", func() {
+			var (
+				clientset  *kubernetes.Clientset
+				volumeData *helpers.VolumeData
+			)
+
 			initialNodeCount := *cluster.AKSConfig.NodePools[0].Count
 
 			By("scaling up the nodepool", func() {
-				var err error
+				snapshot, err := helpers.TakeClusterSnapshot(ctx.RancherClient, cluster.ID)
+				Expect(err).To(BeNil())
 				cluster, err = helper.ScaleNodePool(cluster, ctx.RancherClient, initialNodeCount+1)
 				Expect(err).To(BeNil())
-				err = clusters.WaitClusterToBeUpgraded(ctx.RancherClient, cluster.ID)
+				cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
 				Expect(err).To(BeNil())
+				err = helpers.WaitForNodeCountPerPool(ctx.RancherClient, cluster.ID, helper.NodePoolLabel, helper.NodeCountPerPool(cluster), true)
+				Expect(err).To(BeNil())
+				diff, err := helpers.DiffClusterSince(ctx.RancherClient, cluster.ID, snapshot)
+				Expect(err).To(BeNil())
+				Expect(diff.Config.Except(helper.ScaleNodePoolFields...)).To(BeEmpty())
+				Expect(diff.UpstreamSpec.Except(helper.ScaleNodePoolFields...)).To(BeEmpty())
 				for i := range cluster.AKSConfig.NodePools {
 					Expect(*cluster.AKSConfig.NodePools[i].Count).To(BeNumerically("==", initialNodeCount+1))
 				}
 			})
 
-			By("scaling down the nodepool", func() {
+			By("writing data to a persistent volume", func() {
 				var err error
+				clientset, err = helpers.GetDownstreamClientset(ctx.RancherClient, cluster.ID)
+				Expect(err).To(BeNil())
+				volumeData, err = helpers.WriteVolumeData(clientset)
+				Expect(err).To(BeNil())
+				DeferCleanup(helpers.DeleteVolumeData, clientset, volumeData)
+			})
+
+			By("scaling down the nodepool", func() {
+				snapshot, err := helpers.TakeClusterSnapshot(ctx.RancherClient, cluster.ID)
+				Expect(err).To(BeNil())
 				cluster, err = helper.ScaleNodePool(cluster, ctx.RancherClient, initialNodeCount)
 				Expect(err).To(BeNil())
-				err = clusters.WaitClusterToBeUpgraded(ctx.RancherClient, cluster.ID)
+				cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+				Expect(err).To(BeNil())
+				err = helpers.WaitForNodeCountPerPool(ctx.RancherClient, cluster.ID, helper.NodePoolLabel, helper.NodeCountPerPool(cluster), true)
 				Expect(err).To(BeNil())
+				diff, err := helpers.DiffClusterSince(ctx.RancherClient, cluster.ID, snapshot)
+				Expect(err).To(BeNil())
+				Expect(diff.Config.Except(helper.ScaleNodePoolFields...)).To(BeEmpty())
+				Expect(diff.UpstreamSpec.Except(helper.ScaleNodePoolFields...)).To(BeEmpty())
 				for i := range cluster.AKSConfig.NodePools {
 					Expect(*cluster.AKSConfig.NodePools[i].Count).To(BeNumerically("==", initialNodeCount))
 				}
 			})
+
+			By("verifying the persistent volume data from a rescheduled pod", func() {
+				reader, err := helpers.VerifyVolumeData(clientset, volumeData)
+				Expect(err).To(BeNil())
+				// the nodepool picks the node to remove, so the volume only has to move if its node was the one removed
+				writerGone, err := helpers.IsVolumeNodeGone(clientset, volumeData.Writer)
+				Expect(err).To(BeNil())
+				if writerGone {
+					Expect(reader).ToNot(Equal(volumeData.Writer))
+				} else {
+					AddReportEntry("persistent volume not rescheduled", "the scale down kept node "+volumeData.Writer.Name+", which wrote the data")
+				}
+			})
 		})
 	})
 
@@ -0,0 +1,111 @@
+package p1_test
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	management "github.com/rancher/rancher/tests/framework/clients/rancher/generated/management/v3"
+	"github.com/rancher/rancher/tests/framework/extensions/clusters"
+	nodestat "github.com/rancher/rancher/tests/framework/extensions/nodes"
+	"github.com/rancher/rancher/tests/framework/extensions/workloads/pods"
+	"github.com/rancher/rancher/tests/framework/pkg/config"
+	namegen "github.com/rancher/rancher/tests/framework/pkg/namegenerator"
+	"k8s.io/utils/pointer"
+
+	"github.com/valaparthvi/highlander-tests/hosted/aks/helper"
+	"github.com/valaparthvi/highlander-tests/hosted/helpers"
+)
+
+var _ = Describe("P1Networking", func() {
+	var (
+		clusterName string
+		ctx         helpers.Context
+		cluster     *management.Cluster
+	)
+	var _ = BeforeEach(func() {
+		clusterName = namegen.AppendRandomString("akshostcluster")
+		ctx = helpers.CommonBeforeSuite("aks")
+		cluster = nil
+	})
+	AfterEach(func() {
+		if cluster == nil {
+			return
+		}
+		err := helper.DeleteAKSHostCluster(cluster, ctx.RancherClient)
+		Expect(err).To(BeNil())
+	})
+
+	DescribeTable("a cluster is created with networking settings",
+		func(networkPlugin, networkPolicy, loadBalancerSKU, outboundType string) {
+			networkingConfig := new(helper.NetworkingConfig)
+			if outboundType == "userDefinedRouting" {
+				// userDefinedRouting requires a subnet with a route table to an egress appliance, which cannot be created on the fly
+				config.LoadConfig(helper.NetworkingConfigConfigurationFileKey, networkingConfig)
+				if networkingConfig.Subnet == "" {
+					Skip("subnet is not set in the " + helper.NetworkingConfigConfigurationFileKey + " config")
+				}
+			}
+
+			var err error
+			cluster, err = helper.CreateAKSHostedClusterWithConfig(ctx.RancherClient, clusterName, ctx.CloudCred.ID, false, func(aksConfig *management.AKSClusterConfigSpec) {
+				aksConfig.ResourceGroup = clusterName
+				aksConfig.DNSPrefix = pointer.String(clusterName + "-dns")
+				aksConfig.NetworkPlugin = pointer.String(networkPlugin)
+				aksConfig.LoadBalancerSKU = pointer.String(loadBalancerSKU)
+				if networkPolicy != "" {
+					aksConfig.NetworkPolicy = pointer.String(networkPolicy)
+				}
+				if outboundType != "" {
+					aksConfig.OutboundType = pointer.String(outboundType)
+				}
+				if networkingConfig.Subnet != "" {
+					aksConfig.VirtualNetwork = pointer.String(networkingConfig.VirtualNetwork)
+					aksConfig.VirtualNetworkResourceGroup = pointer.String(networkingConfig.VirtualNetworkResourceGroup)
+					aksConfig.Subnet = pointer.String(networkingConfig.Subnet)
+					aksConfig.NetworkServiceCIDR = pointer.String(networkingConfig.ServiceCIDR)
+					aksConfig.NetworkDNSServiceIP = pointer.String(networkingConfig.DNSServiceIP)
+				}
+			})
+			Expect(err).To(BeNil())
+			cluster, err = helpers.WaitUntilClusterIsReady(cluster, ctx.RancherClient)
+			Expect(err).To(BeNil())
+
+			By("checking the networking settings are reported by the upstream spec", func() {
+				// Azure reports enum values in a different case than the one accepted on creation, for e.g. standard instead of Standard
+				upstreamSpec := cluster.AKSStatus.UpstreamSpec
+				Expect(strings.ToLower(pointer.StringDeref(upstreamSpec.NetworkPlugin, ""))).To(Equal(strings.ToLower(networkPlugin)))
+				Expect(strings.ToLower(pointer.StringDeref(upstreamSpec.LoadBalancerSKU, ""))).To(Equal(strings.ToLower(loadBalancerSKU)))
+				if networkPolicy != "" {
+					Expect(strings.ToLower(pointer.StringDeref(upstreamSpec.NetworkPolicy, ""))).To(Equal(strings.ToLower(networkPolicy)))
+				}
+				if outboundType != "" {
+					Expect(strings.ToLower(pointer.StringDeref(upstreamSpec.OutboundType, ""))).To(Equal(strings.ToLower(outboundType)))
+				}
+			})
+
+			By("checking service account token secret", func() {
+				success, err := clusters.CheckServiceAccountTokenSecret(ctx.RancherClient, clusterName)
+				Expect(err).To(BeNil())
+				Expect(success).To(BeTrue())
+			})
+
+			By("checking all management nodes are ready", func() {
+				err := nodestat.AllManagementNodeReady(ctx.RancherClient, cluster.ID, helpers.Timeout)
+				Expect(err).To(BeNil())
+			})
+
+			By("checking all pods are ready", func() {
+				podErrors := pods.StatusPods(ctx.RancherClient, cluster.ID)
+				Expect(podErrors).To(BeEmpty())
+			})
+		},
+		Entry("kubenet network plugin", "kubenet", "", "Standard", ""),
+		Entry("azure CNI network plugin", "azure", "", "Standard", ""),
+		Entry("kubenet network plugin with Calico network policy", "kubenet", "calico", "Standard", ""),
+		Entry("azure CNI network plugin with Calico network policy", "azure", "calico", "Standard", ""),
+		Entry("azure CNI network plugin with Azure network policy", "azure", "azure", "Standard", ""),
+		Entry("basic load balancer SKU", "kubenet", "", "Basic", ""),
+		Entry("userDefinedRouting outbound type", "azure", "", "Standard", "userDefinedRouting"),
+	)
+})
@@ -0,0 +1,160 @@
+package p1_test
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	management "github.com/rancher/rancher/tests/framework/clients/rancher/generated/management/v3"
+	"github.com/rancher/rancher/tests/framework/extensions/clusters/aks"
+	"github.com/rancher/rancher/tests/framework/pkg/config"
+	namegen "github.com/rancher/rancher/tests/framework/pkg/namegenerator"
+
+	"github.com/valaparthvi/highlander-tests/hosted/aks/helper"
+	"github.com/valaparthvi/highlander-tests/hosted/helpers"
+)
+
+var _ = Describe("P1NodePoolMode", func() {
+	var (
+		clusterName    string
+		ctx            helpers.Context
+		cluster        *management.Cluster
+		systemNodePool string
+	)
+	var _ = BeforeEach(func() {
+		clusterName = namegen.AppendRandomString("akshostcluster")
+		ctx = helpers.CommonBeforeSuite("aks")
+		cluster = nil
+
+		systemNodePools := helper.SystemNodePoolNames(helper.AksHostNodeConfig())
+		if len(systemNodePools) != 1 {
+			Skip(fmt.Sprintf("the mode rules are checked against the last System nodepool, but the aksClusterConfig has %d System nodepools", len(systemNodePools)))
+		}
+		systemNodePool = systemNodePools[0]
+
+		helpers.PreserveConfig()
+		aksConfig := new(aks.ClusterConfig)
+		config.LoadAndUpdateConfig(aks.AKSClusterConfigConfigurationFileKey, aksConfig, func() {
+			aksConfig.ResourceGroup = clusterName
+			dnsPrefix := clusterName + "-dns"
+			aksConfig.DNSPrefix = &dnsPrefix
+		})
+		var err error
+		cluster, err = aks.CreateAKSHostedCluster(ctx.RancherClient, clusterName, ctx.CloudCred.ID, false, false, false, false, map[string]string{})
+		Expect(err).To(BeNil())
+		cluster, err = helpers.WaitUntilClusterIsReady(cluster, ctx.RancherClient)
+		Expect(err).To(BeNil())
+	})
+	AfterEach(func() {
+		if cluster == nil {
+			return
+		}
+		err := helper.DeleteAKSHostCluster(cluster, ctx.RancherClient)
+		Expect(err).To(BeNil())
+	})
+
+	// checkNodePool checks the mode and node count of the nodepool, as reported by the upstream spec and by Azure
+	checkNodePool := func(nodePoolName, mode string, count int64) {
+		upstreamNodePool, err := helper.UpstreamNodePool(cluster, nodePoolName)
+		Expect(err).To(BeNil())
+		Expect(upstreamNodePool.Mode).To(Equal(mode))
+		Expect(*upstreamNodePool.Count).To(BeNumerically("==", count))
+
+		azureNodePool, err := helper.GetNodePoolOnAzure(cluster.AKSConfig.ResourceGroup, cluster.AKSConfig.ClusterName, nodePoolName)
+		Expect(err).To(BeNil())
+		Expect(azureNodePool.Mode).To(Equal(mode))
+		Expect(azureNodePool.Count).To(BeNumerically("==", count))
+	}
+
+	It("should reject removing the last System nodepool", func() {
+		userNodePool := namegen.RandStringLower(5)
+
+		By("adding a User nodepool", func() {
+			var err error
+			cluster, err = helper.AddNodePoolWithMode(cluster, ctx.RancherClient, userNodePool, helper.UserMode)
+			Expect(err).To(BeNil())
+			cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+			Expect(err).To(BeNil())
+			checkNodePool(userNodePool, helper.UserMode, 1)
+		})
+
+		var systemNodeCount int64
+		By("recording the node count of the System nodepool", func() {
+			upstreamNodePool, err := helper.UpstreamNodePool(cluster, systemNodePool)
+			Expect(err).To(BeNil())
+			systemNodeCount = *upstreamNodePool.Count
+		})
+
+		By("deleting the last System nodepool", func() {
+			err := helpers.VerifyChangeIsRejected(ctx.RancherClient, cluster, func() (*management.Cluster, error) {
+				return helper.DeleteNodePoolByName(cluster, ctx.RancherClient, systemNodePool)
+			})
+			Expect(err).To(BeNil())
+		})
+
+		By("converting the last System nodepool to User mode", func() {
+			err := helpers.VerifyChangeIsRejected(ctx.RancherClient, cluster, func() (*management.Cluster, error) {
+				return helper.UpdateNodePoolMode(cluster, ctx.RancherClient, systemNodePool, helper.UserMode)
+			})
+			Expect(err).To(BeNil())
+		})
+
+		By("checking the System nodepool is unchanged", func() {
+			var err error
+			cluster, err = ctx.RancherClient.Management.Cluster.ByID(cluster.ID)
+			Expect(err).To(BeNil())
+			checkNodePool(systemNodePool, helper.SystemMode, systemNodeCount)
+		})
+	})
+
+	It("should be possible to scale a User nodepool to zero and promote it to System", func() {
+		userNodePool := namegen.RandStringLower(5)
+
+		By("adding a User nodepool", func() {
+			var err error
+			cluster, err = helper.AddNodePoolWithMode(cluster, ctx.RancherClient, userNodePool, helper.UserMode)
+			Expect(err).To(BeNil())
+			cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+			Expect(err).To(BeNil())
+			checkNodePool(userNodePool, helper.UserMode, 1)
+		})
+
+		By("scaling the User nodepool to zero", func() {
+			var err error
+			cluster, err = helper.ScaleNodePoolByName(cluster, ctx.RancherClient, userNodePool, 0)
+			Expect(err).To(BeNil())
+			cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+			Expect(err).To(BeNil())
+			err = helpers.WaitForNodeCountPerPool(ctx.RancherClient, cluster.ID, helper.NodePoolLabel, helper.NodeCountPerPool(cluster), true)
+			Expect(err).To(BeNil())
+			checkNodePool(userNodePool, helper.UserMode, 0)
+		})
+
+		By("scaling the User nodepool back up", func() {
+			var err error
+			cluster, err = helper.ScaleNodePoolByName(cluster, ctx.RancherClient, userNodePool, 1)
+			Expect(err).To(BeNil())
+			cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+			Expect(err).To(BeNil())
+			checkNodePool(userNodePool, helper.UserMode, 1)
+		})
+
+		By("promoting the User nodepool to System", func() {
+			var err error
+			cluster, err = helper.UpdateNodePoolMode(cluster, ctx.RancherClient, userNodePool, helper.SystemMode)
+			Expect(err).To(BeNil())
+			cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+			Expect(err).To(BeNil())
+			checkNodePool(userNodePool, helper.SystemMode, 1)
+		})
+
+		By("deleting the original System nodepool", func() {
+			var err error
+			cluster, err = helper.DeleteNodePoolByName(cluster, ctx.RancherClient, systemNodePool)
+			Expect(err).To(BeNil())
+			cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+			Expect(err).To(BeNil())
+			Expect(helper.SystemNodePoolNames(cluster.AKSConfig.NodePools)).To(ConsistOf(userNodePool))
+		})
+	})
+})
@@ -0,0 +1,133 @@
+package p1_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rancher/rancher/tests/framework/clients/rancher"
+	management "github.com/rancher/rancher/tests/framework/clients/rancher/generated/management/v3"
+	"github.com/rancher/rancher/tests/framework/extensions/clusters/aks"
+	"github.com/rancher/rancher/tests/framework/extensions/users"
+	"github.com/rancher/rancher/tests/framework/pkg/config"
+	namegen "github.com/rancher/rancher/tests/framework/pkg/namegenerator"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/valaparthvi/highlander-tests/hosted/aks/helper"
+	"github.com/valaparthvi/highlander-tests/hosted/helpers"
+)
+
+var _ = Describe("P1RBAC", func() {
+	var (
+		clusterName string
+		ctx         helpers.Context
+		cluster     *management.Cluster
+	)
+	var _ = BeforeEach(func() {
+		clusterName = namegen.AppendRandomString("akshostcluster")
+		ctx = helpers.CommonBeforeSuite("aks")
+
+		helpers.PreserveConfig()
+		aksConfig := new(aks.ClusterConfig)
+		config.LoadAndUpdateConfig(aks.AKSClusterConfigConfigurationFileKey, aksConfig, func() {
+			aksConfig.ResourceGroup = clusterName
+			dnsPrefix := clusterName + "-dns"
+			aksConfig.DNSPrefix = &dnsPrefix
+		})
+		var err error
+		cluster, err = aks.CreateAKSHostedCluster(ctx.RancherClient, clusterName, ctx.CloudCred.ID, false, false, false, false, map[string]string{})
+		Expect(err).To(BeNil())
+		cluster, err = helpers.WaitUntilClusterIsReady(cluster, ctx.RancherClient)
+		Expect(err).To(BeNil())
+	})
+	AfterEach(func() {
+		if cluster == nil {
+			return
+		}
+		err := helper.DeleteAKSHostCluster(cluster, ctx.RancherClient)
+		Expect(err).To(BeNil())
+	})
+
+	It("should enforce the project and cluster roles of standard users", func() {
+		var (
+			project       *management.Project
+			namespace     *corev1.Namespace
+			memberClient  *rancher.Client
+			ownerClient   *rancher.Client
+			initNodeCount = *cluster.AKSConfig.NodePools[0].Count
+		)
+
+		By("creating a project and a namespace", func() {
+			var err error
+			project, namespace, err = helpers.CreateProjectAndNamespace(ctx.RancherClient, cluster.ID, namegen.AppendRandomString("highlander-rbac"))
+			Expect(err).To(BeNil())
+		})
+
+		By("creating a project member and a cluster owner", func() {
+			member, userClient, err := helpers.CreateStandardUser(ctx.RancherClient)
+			Expect(err).To(BeNil())
+			err = users.AddProjectMember(ctx.RancherClient, project, member, helpers.ProjectMemberRole, nil)
+			Expect(err).To(BeNil())
+			memberClient, err = userClient.ReLogin()
+			Expect(err).To(BeNil())
+
+			owner, userClient, err := helpers.CreateStandardUser(ctx.RancherClient)
+			Expect(err).To(BeNil())
+			err = users.AddClusterRoleToUser(ctx.RancherClient, cluster, owner, helpers.ClusterOwnerRole, nil)
+			Expect(err).To(BeNil())
+			ownerClient, err = userClient.ReLogin()
+			Expect(err).To(BeNil())
+		})
+
+		By("checking the project member can only list the pods of its project", func() {
+			allowed, err := helpers.CanListPods(memberClient, cluster.ID, namespace.Name)
+			Expect(err).To(BeNil())
+			Expect(allowed).To(BeTrue())
+			allowed, err = helpers.CanListPods(memberClient, cluster.ID, metav1.NamespaceSystem)
+			Expect(err).To(BeNil())
+			Expect(allowed).To(BeFalse())
+		})
+
+		By("checking the project member cannot scale the nodepool", func() {
+			// the member can read the cluster, so the update itself is what must be forbidden
+			_, err := memberClient.Management.Cluster.ByID(cluster.ID)
+			Expect(err).To(BeNil())
+			err = helpers.VerifyChangeIsRejected(ctx.RancherClient, cluster, func() (*management.Cluster, error) {
+				updatedCluster, err := helper.ScaleNodePool(cluster, memberClient, initNodeCount+1)
+				Expect(helpers.IsForbidden(err)).To(BeTrue())
+				return updatedCluster, err
+			})
+			Expect(err).To(BeNil())
+		})
+
+		By("checking the project member cannot delete the cluster", func() {
+			err := helper.DeleteAKSHostCluster(cluster, memberClient)
+			Expect(helpers.IsForbidden(err)).To(BeTrue())
+			cluster, err = helpers.WaitUntilClusterIsActive(ctx.RancherClient, cluster.ID)
+			Expect(err).To(BeNil())
+		})
+
+		By("checking the cluster owner can list the pods of every namespace", func() {
+			allowed, err := helpers.CanListPods(ownerClient, cluster.ID, metav1.NamespaceSystem)
+			Expect(err).To(BeNil())
+			Expect(allowed).To(BeTrue())
+		})
+
+		By("checking the cluster owner can scale the nodepool", func() {
+			var err error
+			cluster, err = helper.ScaleNodePool(cluster, ownerClient, initNodeCount+1)
+			Expect(err).To(BeNil())
+			cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+			Expect(err).To(BeNil())
+			err = helpers.WaitForNodeCountPerPool(ctx.RancherClient, cluster.ID, helper.NodePoolLabel, helper.NodeCountPerPool(cluster), true)
+			Expect(err).To(BeNil())
+		})
+
+		By("checking the cluster owner can delete the cluster", func() {
+			err := helper.DeleteAKSHostCluster(cluster, ownerClient)
+			Expect(err).To(BeNil())
+			err = helpers.WaitUntilClusterIsDeleted(ctx.RancherClient, cluster.ID)
+			Expect(err).To(BeNil())
+			cluster = nil
+		})
+	})
+})
@@ -0,0 +1,39 @@
+package p1_test
+
+import (
+	management "github.com/rancher/rancher/tests/framework/clients/rancher/generated/management/v3"
+	"github.com/rancher/rancher/tests/framework/extensions/clusters/aks"
+	"github.com/rancher/rancher/tests/framework/pkg/config"
+	namegen "github.com/rancher/rancher/tests/framework/pkg/namegenerator"
+
+	"github.com/valaparthvi/highlander-tests/hosted/aks/helper"
+	"github.com/valaparthvi/highlander-tests/hosted/helpers"
+)
+
+var _ = helpers.DescribeVersionSkew(helpers.VersionSkewProvider{
+	Cloud: "aks",
+	ListVersions: func(ctx helpers.Context) ([]string, error) {
+		return helper.ListSingleVariantAKSAvailableVersions(ctx.RancherClient, ctx.CloudCred.ID, "eastus")
+	},
+	CreateCluster: func(ctx helpers.Context, k8sVersion string) (*management.Cluster, error) {
+		clusterName := namegen.AppendRandomString("akshostcluster")
+		helpers.PreserveConfig()
+		aksConfig := new(aks.ClusterConfig)
+		config.LoadAndUpdateConfig(aks.AKSClusterConfigConfigurationFileKey, aksConfig, func() {
+			aksConfig.ResourceGroup = clusterName
+			dnsPrefix := clusterName + "-dns"
+			aksConfig.DNSPrefix = &dnsPrefix
+			aksConfig.KubernetesVersion = &k8sVersion
+		})
+		return aks.CreateAKSHostedCluster(ctx.RancherClient, clusterName, ctx.CloudCred.ID, false, false, false, false, map[string]string{})
+	},
+	DeleteCluster:       helper.DeleteAKSHostCluster,
+	UpgradeControlPlane: helper.UpgradeClusterKubernetesVersion,
+	UpgradeNodes:        helper.UpgradeNodeKubernetesVersion,
+	// AKS reports for e.g. "Node pool version 1.28.3 and control plane version 1.27.7 are incompatible. Minor version of node pool version 28 is bigger than control plane version 27."
+	NewerNodesRejection: `(?i)node pool version \S+ and control plane version \S+ are incompatible.*bigger than control plane version`,
+	// for e.g. "... are incompatible. Minor version of node pool cannot be more than 2 versions less than control plane's version."
+	NodeSkewRejection: `(?i)node pool version \S+ and control plane version \S+ are incompatible.*cannot be more than \d+ versions? less than control plane`,
+	// for e.g. "Upgrading Kubernetes version 1.26.6 to 1.28.3 is not allowed. Available upgrades: ..."
+	SkippedMinorRejection: `(?i)upgrading kubernetes version \S+ to \S+ is not allowed`,
+})
@@ -0,0 +1,115 @@
+package p1_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	management "github.com/rancher/rancher/tests/framework/clients/rancher/generated/management/v3"
+	"github.com/rancher/rancher/tests/framework/extensions/clusters"
+	namegen "github.com/rancher/rancher/tests/framework/pkg/namegenerator"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/pointer"
+
+	"github.com/valaparthvi/highlander-tests/hosted/aks/helper"
+	"github.com/valaparthvi/highlander-tests/hosted/helpers"
+)
+
+// windowsImage is a multi-arch image small enough to be pulled quickly on Windows nodes
+const windowsImage = "mcr.microsoft.com/oss/kubernetes/pause:3.9"
+
+var _ = Describe("P1Windows", func() {
+	var (
+		clusterName string
+		ctx         helpers.Context
+		cluster     *management.Cluster
+	)
+	var _ = BeforeEach(func() {
+		clusterName = namegen.AppendRandomString("akshostcluster")
+		ctx = helpers.CommonBeforeSuite("aks")
+		cluster = nil
+	})
+	AfterEach(func() {
+		if cluster == nil {
+			return
+		}
+		err := helper.DeleteAKSHostCluster(cluster, ctx.RancherClient)
+		Expect(err).To(BeNil())
+	})
+
+	DescribeTable("a cluster is created with the azure network plugin and a Windows nodepool is added",
+		func(windowsPreferedCluster bool) {
+			var err error
+			// Windows nodepools are not supported with the kubenet network plugin
+			cluster, err = helper.CreateAKSHostedClusterWithConfig(ctx.RancherClient, clusterName, ctx.CloudCred.ID, windowsPreferedCluster, func(aksConfig *management.AKSClusterConfigSpec) {
+				aksConfig.ResourceGroup = clusterName
+				aksConfig.DNSPrefix = pointer.String(clusterName + "-dns")
+				aksConfig.NetworkPlugin = pointer.String("azure")
+			})
+			Expect(err).To(BeNil())
+			cluster, err = helpers.WaitUntilClusterIsReady(cluster, ctx.RancherClient)
+			Expect(err).To(BeNil())
+			Expect(cluster.WindowsPreferedCluster).To(Equal(windowsPreferedCluster))
+
+			var nodePoolName string
+			currentNodePoolNumber := len(cluster.AKSConfig.NodePools)
+
+			By("adding a Windows nodepool", func() {
+				var err error
+				cluster, err = helper.AddWindowsNodePool(cluster, ctx.RancherClient)
+				Expect(err).To(BeNil())
+				cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+				Expect(err).To(BeNil())
+				Expect(len(cluster.AKSConfig.NodePools)).To(BeNumerically("==", currentNodePoolNumber+1))
+				nodePoolName = *cluster.AKSConfig.NodePools[len(cluster.AKSConfig.NodePools)-1].Name
+			})
+
+			By("checking the nodes report their OS", func() {
+				nodes, err := helpers.ListDownstreamNodes(ctx.RancherClient, cluster.ID, "")
+				Expect(err).To(BeNil())
+				for _, node := range nodes {
+					expectedOS := "linux"
+					if node.Labels[helper.NodePoolLabel] == nodePoolName {
+						expectedOS = "windows"
+					}
+					Expect(node.Labels).To(HaveKeyWithValue(corev1.LabelOSStable, expectedOS))
+					Expect(node.Status.NodeInfo.OperatingSystem).To(Equal(expectedOS))
+				}
+
+				windowsNodes, err := helpers.ListDownstreamNodes(ctx.RancherClient, cluster.ID, helper.NodePoolLabel+"="+nodePoolName)
+				Expect(err).To(BeNil())
+				Expect(windowsNodes).ToNot(BeEmpty())
+			})
+
+			By("scheduling a Windows workload", func() {
+				deploymentName := namegen.AppendRandomString("windows")
+				_, err := helpers.CreateDeploymentOnNodes(ctx.RancherClient, cluster.ID, "default", deploymentName, windowsImage, map[string]string{corev1.LabelOSStable: "windows"})
+				Expect(err).To(BeNil())
+				_, err = helpers.WaitForDeploymentToBeAvailable(ctx.RancherClient, cluster.ID, "default", deploymentName)
+				Expect(err).To(BeNil())
+
+				windowsNodes, err := helpers.ListDownstreamNodes(ctx.RancherClient, cluster.ID, helper.NodePoolLabel+"="+nodePoolName)
+				Expect(err).To(BeNil())
+				var windowsNodeNames []string
+				for _, node := range windowsNodes {
+					windowsNodeNames = append(windowsNodeNames, node.Name)
+				}
+				pods, err := helpers.ListDownstreamPods(ctx.RancherClient, cluster.ID, "default", "workload.user.cattle.io/workloadselector=apps.deployment-default-"+deploymentName)
+				Expect(err).To(BeNil())
+				Expect(pods).ToNot(BeEmpty())
+				for _, pod := range pods {
+					Expect(pod.Spec.NodeName).To(BeElementOf(windowsNodeNames))
+				}
+			})
+
+			By("deleting the Windows nodepool", func() {
+				var err error
+				cluster, err = helper.DeleteNodePool(cluster, ctx.RancherClient)
+				Expect(err).To(BeNil())
+				cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+				Expect(err).To(BeNil())
+				Expect(len(cluster.AKSConfig.NodePools)).To(BeNumerically("==", currentNodePoolNumber))
+			})
+		},
+		Entry("Linux preferred cluster", false),
+		Entry("Windows preferred cluster", true),
+	)
+})
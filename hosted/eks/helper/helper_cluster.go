@@ -1,47 +1,52 @@
 package helper
 
 import (
+	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/rancher/rancher/tests/framework/clients/rancher"
 	management "github.com/rancher/rancher/tests/framework/clients/rancher/generated/management/v3"
 	"github.com/rancher/rancher/tests/framework/extensions/clusters/kubernetesversions"
 	"github.com/rancher/rancher/tests/framework/pkg/config"
 	namegen "github.com/rancher/rancher/tests/framework/pkg/namegenerator"
+	kwait "k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/utils/pointer"
 
 	"github.com/epinio/epinio/acceptance/helpers/proc"
 	"github.com/pkg/errors"
+
+	"github.com/valaparthvi/highlander-tests/hosted/helpers"
 )
 
+// NodeGroupLabel is the label EKS adds to every node with the name of the nodegroup it belongs to
+const NodeGroupLabel = "eks.amazonaws.com/nodegroup"
+
+// CapacityTypeLabel is the label EKS adds to every node of a managed nodegroup with its capacity type, i.e. ON_DEMAND or SPOT
+const CapacityTypeLabel = "eks.amazonaws.com/capacityType"
+
+// EBSCSIDriverName is the name of the CSI driver installed by the EBS CSI driver add-on, without which the default StorageClass
+// cannot provision volumes since 1.23
+const EBSCSIDriverName = "ebs.csi.aws.com"
+
 // UpgradeClusterKubernetesVersion upgrades the k8s version to the value defined by upgradeToVersion.
 func UpgradeClusterKubernetesVersion(cluster *management.Cluster, upgradeToVersion *string, client *rancher.Client) (*management.Cluster, error) {
-	upgradedCluster := new(management.Cluster)
-	upgradedCluster.Name = cluster.Name
-	upgradedCluster.EKSConfig = cluster.EKSConfig
-	upgradedCluster.EKSConfig.KubernetesVersion = upgradeToVersion
-
-	cluster, err := client.Management.Cluster.Update(cluster, &upgradedCluster)
-	if err != nil {
-		return nil, err
-	}
-	return cluster, nil
+	return updateEKSConfig(cluster, client, func(eksConfig *management.EKSClusterConfigSpec) error {
+		eksConfig.KubernetesVersion = upgradeToVersion
+		return nil
+	})
 }
 
 // UpgradeNodeKubernetesVersion upgrades the k8s version of nodegroup to the value defined by upgradeToVersion.
 func UpgradeNodeKubernetesVersion(cluster *management.Cluster, upgradeToVersion *string, client *rancher.Client) (*management.Cluster, error) {
-	upgradedCluster := new(management.Cluster)
-	upgradedCluster.Name = cluster.Name
-	upgradedCluster.EKSConfig = cluster.EKSConfig
-	for i := range upgradedCluster.EKSConfig.NodeGroups {
-		upgradedCluster.EKSConfig.NodeGroups[i].Version = upgradeToVersion
-	}
-
-	cluster, err := client.Management.Cluster.Update(cluster, &upgradedCluster)
-	if err != nil {
-		return nil, err
-	}
-	return cluster, nil
+	return updateEKSConfig(cluster, client, func(eksConfig *management.EKSClusterConfigSpec) error {
+		for i := range eksConfig.NodeGroups {
+			eksConfig.NodeGroups[i].Version = upgradeToVersion
+		}
+		return nil
+	})
 }
 
 // DeleteEKSHostCluster deletes the EKS cluster
@@ -51,65 +56,240 @@ func DeleteEKSHostCluster(cluster *management.Cluster, client *rancher.Client) e
 
 // AddNodeGroup adds a nodegroup to the list
 func AddNodeGroup(cluster *management.Cluster, increaseBy int, client *rancher.Client) (*management.Cluster, error) {
-	upgradedCluster := new(management.Cluster)
-	upgradedCluster.Name = cluster.Name
-	upgradedCluster.EKSConfig = cluster.EKSConfig
+	return addNodeGroup(cluster, increaseBy, client, func(*management.NodeGroup) error { return nil })
+}
+
+// AddSpotNodeGroup adds a nodegroup with the spot capacity type to the list;
+// EKS does not accept an instance type for spot nodegroups, so the configured one is requested as spot instance type
+func AddSpotNodeGroup(cluster *management.Cluster, increaseBy int, client *rancher.Client) (*management.Cluster, error) {
+	return addNodeGroup(cluster, increaseBy, client, func(ng *management.NodeGroup) error {
+		if ng.InstanceType == nil {
+			return errors.New("no instance type configured for the spot nodegroup")
+		}
+		spotInstanceTypes := []string{*ng.InstanceType}
+		ng.InstanceType = nil
+		ng.RequestSpotInstances = pointer.Bool(true)
+		ng.SpotInstanceTypes = &spotInstanceTypes
+		return nil
+	})
+}
+
+// addNodeGroup adds increaseBy nodegroups for each nodegroup of the config, after applying updateFunc to them; the update is aborted if updateFunc returns an error
+func addNodeGroup(cluster *management.Cluster, increaseBy int, client *rancher.Client, updateFunc func(*management.NodeGroup) error) (*management.Cluster, error) {
 	nodeConfig := EksHostNodeConfig()
 
-	for i := 1; i <= increaseBy; i++ {
-		for _, ng := range nodeConfig {
-			newNodeGroup := management.NodeGroup{
-				NodegroupName: pointer.String(namegen.AppendRandomString("nodegroup")),
-				DesiredSize:   ng.DesiredSize,
-				DiskSize:      ng.DiskSize,
-				InstanceType:  ng.InstanceType,
-				MaxSize:       ng.MaxSize,
-				MinSize:       ng.MinSize,
+	return updateEKSConfig(cluster, client, func(eksConfig *management.EKSClusterConfigSpec) error {
+		for i := 1; i <= increaseBy; i++ {
+			for _, ng := range nodeConfig {
+				newNodeGroup := management.NodeGroup{
+					NodegroupName: pointer.String(namegen.AppendRandomString("nodegroup")),
+					DesiredSize:   ng.DesiredSize,
+					DiskSize:      ng.DiskSize,
+					InstanceType:  ng.InstanceType,
+					MaxSize:       ng.MaxSize,
+					MinSize:       ng.MinSize,
+				}
+				if err := updateFunc(&newNodeGroup); err != nil {
+					return err
+				}
+				eksConfig.NodeGroups = append(eksConfig.NodeGroups, newNodeGroup)
 			}
-			upgradedCluster.EKSConfig.NodeGroups = append(upgradedCluster.EKSConfig.NodeGroups, newNodeGroup)
 		}
+		return nil
+	})
+}
+
+// AddNodeGroupWithCustomAMI adds a nodegroup that uses the Rancher-managed launch template customized with imageID, userData and ec2SshKey;
+// empty values are not set so that EKS defaults are used for them
+func AddNodeGroupWithCustomAMI(cluster *management.Cluster, client *rancher.Client, imageID, userData, ec2SshKey string) (*management.Cluster, error) {
+	ng := EksHostNodeConfig()[0]
+
+	newNodeGroup := management.NodeGroup{
+		NodegroupName: pointer.String(namegen.AppendRandomString("nodegroup")),
+		DesiredSize:   ng.DesiredSize,
+		DiskSize:      ng.DiskSize,
+		InstanceType:  ng.InstanceType,
+		MaxSize:       ng.MaxSize,
+		MinSize:       ng.MinSize,
 	}
-	cluster, err := client.Management.Cluster.Update(cluster, &upgradedCluster)
-	if err != nil {
-		return nil, err
+	if imageID != "" {
+		newNodeGroup.ImageID = pointer.String(imageID)
 	}
-	return cluster, nil
+	if userData != "" {
+		newNodeGroup.UserData = pointer.String(userData)
+	}
+	if ec2SshKey != "" {
+		newNodeGroup.Ec2SshKey = pointer.String(ec2SshKey)
+	}
+
+	return updateEKSConfig(cluster, client, func(eksConfig *management.EKSClusterConfigSpec) error {
+		eksConfig.NodeGroups = append(eksConfig.NodeGroups, newNodeGroup)
+		return nil
+	})
+}
+
+// AddNodeGroupWithLaunchTemplate adds a nodegroup that uses the user-supplied launchTemplate;
+// instance type, AMI, user data and SSH key are taken from the launch template, so they are not set on the nodegroup
+func AddNodeGroupWithLaunchTemplate(cluster *management.Cluster, client *rancher.Client, launchTemplate management.LaunchTemplate) (*management.Cluster, error) {
+	ng := EksHostNodeConfig()[0]
+
+	newNodeGroup := management.NodeGroup{
+		NodegroupName:  pointer.String(namegen.AppendRandomString("nodegroup")),
+		DesiredSize:    ng.DesiredSize,
+		MaxSize:        ng.MaxSize,
+		MinSize:        ng.MinSize,
+		LaunchTemplate: &launchTemplate,
+	}
+
+	return updateEKSConfig(cluster, client, func(eksConfig *management.EKSClusterConfigSpec) error {
+		eksConfig.NodeGroups = append(eksConfig.NodeGroups, newNodeGroup)
+		return nil
+	})
+}
+
+// UpdateNodeGroupLaunchTemplateVersion modifies the version of the user-supplied launch template used by the nodegroup named nodeGroupName
+func UpdateNodeGroupLaunchTemplateVersion(cluster *management.Cluster, client *rancher.Client, nodeGroupName string, version int64) (*management.Cluster, error) {
+	return updateNodeGroup(cluster, client, nodeGroupName, func(ng *management.NodeGroup) error {
+		if ng.LaunchTemplate == nil {
+			return errors.Errorf("nodegroup %s does not use a user-supplied launch template", nodeGroupName)
+		}
+		ng.LaunchTemplate.Version = pointer.Int64(version)
+		return nil
+	})
+}
+
+// UpdateNodeGroupUserData modifies the user data of the Rancher-managed launch template used by the nodegroup named nodeGroupName;
+// Rancher creates a new launch template version for it
+func UpdateNodeGroupUserData(cluster *management.Cluster, client *rancher.Client, nodeGroupName, userData string) (*management.Cluster, error) {
+	return updateNodeGroup(cluster, client, nodeGroupName, func(ng *management.NodeGroup) error {
+		ng.UserData = pointer.String(userData)
+		return nil
+	})
 }
 
 // DeleteNodeGroup deletes a nodegroup from the list
 // TODO: Modify this method to delete a custom qty of DeleteNodeGroup, perhaps by adding an `decreaseBy int` arg
 func DeleteNodeGroup(cluster *management.Cluster, client *rancher.Client) (*management.Cluster, error) {
-	upgradedCluster := new(management.Cluster)
-	upgradedCluster.Name = cluster.Name
-	upgradedCluster.EKSConfig = cluster.EKSConfig
+	return updateEKSConfig(cluster, client, func(eksConfig *management.EKSClusterConfigSpec) error {
+		eksConfig.NodeGroups = eksConfig.NodeGroups[1:]
+		return nil
+	})
+}
+
+// ScaleNodeGroup modifies the number of initialNodeCount of all the nodegroups as defined by nodeCount
+func ScaleNodeGroup(cluster *management.Cluster, client *rancher.Client, nodeCount int64) (*management.Cluster, error) {
+	return updateEKSConfig(cluster, client, func(eksConfig *management.EKSClusterConfigSpec) error {
+		for i := range eksConfig.NodeGroups {
+			eksConfig.NodeGroups[i].DesiredSize = pointer.Int64(nodeCount)
+			eksConfig.NodeGroups[i].MaxSize = pointer.Int64(nodeCount)
+			eksConfig.NodeGroups[i].MinSize = pointer.Int64(nodeCount)
+		}
+		return nil
+	})
+}
 
-	upgradedCluster.EKSConfig.NodeGroups = cluster.EKSConfig.NodeGroups[1:]
+// UpdateNodeGroupLabels sets the kubernetes node labels of the nodegroup named nodeGroupName to the value defined by labels;
+// an empty map removes all the labels previously added to the nodegroup
+func UpdateNodeGroupLabels(cluster *management.Cluster, client *rancher.Client, nodeGroupName string, labels map[string]string) (*management.Cluster, error) {
+	return updateNodeGroup(cluster, client, nodeGroupName, func(ng *management.NodeGroup) error {
+		ng.Labels = &labels
+		return nil
+	})
+}
 
-	cluster, err := client.Management.Cluster.Update(cluster, &upgradedCluster)
+// UpdateClusterTags sets the AWS resource tags of the cluster to the value defined by tags;
+// an empty map removes all the tags previously added to the cluster
+func UpdateClusterTags(cluster *management.Cluster, client *rancher.Client, tags map[string]string) (*management.Cluster, error) {
+	return updateEKSConfig(cluster, client, func(eksConfig *management.EKSClusterConfigSpec) error {
+		eksConfig.Tags = &tags
+		return nil
+	})
+}
+
+// AdoptUpstreamSpec waits for the upstream spec of the imported cluster to be populated and adopts it into the EKS config, keeping the identity and credential of the cluster,
+// so that day-2 operations start from the nodegroups that exist on AWS; it then verifies that the nodegroups of the config and the upstream spec agree.
+// The EKS config of an imported cluster only holds what was set on import, i.e. no nodegroups, and adding one would otherwise replace the existing ones;
+// once the operator populates the config on import, this is logged and the config is left as is.
+// On failure, the last known state of the cluster is returned along with the error, so that callers can still clean it up
+func AdoptUpstreamSpec(cluster *management.Cluster, client *rancher.Client) (*management.Cluster, error) {
+	upstreamCluster, err := helpers.WaitForUpstreamSpec(client, cluster.ID)
 	if err != nil {
-		return nil, err
+		return cluster, err
 	}
-	return cluster, nil
-}
+	cluster = upstreamCluster
 
-// ScaleNodeGroup modifies the number of initialNodeCount of all the nodegroups as defined by nodeCount
-func ScaleNodeGroup(cluster *management.Cluster, client *rancher.Client, nodeCount int64) (*management.Cluster, error) {
-	upgradedCluster := new(management.Cluster)
-	upgradedCluster.Name = cluster.Name
-	upgradedCluster.EKSConfig = cluster.EKSConfig
-	for i := range upgradedCluster.EKSConfig.NodeGroups {
-		upgradedCluster.EKSConfig.NodeGroups[i].DesiredSize = pointer.Int64(nodeCount)
-		upgradedCluster.EKSConfig.NodeGroups[i].MaxSize = pointer.Int64(nodeCount)
-		upgradedCluster.EKSConfig.NodeGroups[i].MinSize = pointer.Int64(nodeCount)
+	if len(cluster.EKSConfig.NodeGroups) > 0 {
+		fmt.Println("EKS config of the imported cluster is already populated, the upstream spec workaround is no longer needed: ", cluster.Name)
+	} else {
+		updatedCluster, err := helpers.UpdateCluster(client, cluster.ID, func(upgradedCluster *management.Cluster) error {
+			if upgradedCluster.EKSStatus == nil || upgradedCluster.EKSStatus.UpstreamSpec == nil {
+				return errors.Errorf("upstream spec of cluster %s is not populated", upgradedCluster.Name)
+			}
+			eksConfig := upgradedCluster.EKSStatus.UpstreamSpec
+			eksConfig.AmazonCredentialSecret = upgradedCluster.EKSConfig.AmazonCredentialSecret
+			eksConfig.DisplayName = upgradedCluster.EKSConfig.DisplayName
+			eksConfig.Imported = upgradedCluster.EKSConfig.Imported
+			eksConfig.Region = upgradedCluster.EKSConfig.Region
+			upgradedCluster.EKSConfig = eksConfig
+			return nil
+		})
+		if err != nil {
+			return cluster, err
+		}
+		cluster = updatedCluster
+		fmt.Println("Adopted the upstream spec into the EKS config of the imported cluster: ", cluster.Name)
 	}
 
-	cluster, err := client.Management.Cluster.Update(cluster, &upgradedCluster)
+	diff, err := helpers.DiffConfigWithUpstreamSpec(cluster, "nodeGroups")
 	if err != nil {
-		return nil, err
+		return cluster, err
+	}
+	if len(diff) > 0 {
+		return cluster, errors.Errorf("nodegroups of the EKS config and the upstream spec of cluster %s do not agree: %v", cluster.Name, diff)
 	}
 	return cluster, nil
 }
 
+// updateEKSConfig applies updateFunc to a copy of the latest EKS config of the cluster and updates the cluster with it
+func updateEKSConfig(cluster *management.Cluster, client *rancher.Client, updateFunc func(*management.EKSClusterConfigSpec) error) (*management.Cluster, error) {
+	return helpers.UpdateCluster(client, cluster.ID, func(upgradedCluster *management.Cluster) error {
+		return updateFunc(upgradedCluster.EKSConfig)
+	})
+}
+
+// updateNodeGroup applies updateFunc to the nodegroup named nodeGroupName in the latest EKS config of the cluster and updates the cluster with it
+func updateNodeGroup(cluster *management.Cluster, client *rancher.Client, nodeGroupName string, updateFunc func(*management.NodeGroup) error) (*management.Cluster, error) {
+	return updateEKSConfig(cluster, client, func(eksConfig *management.EKSClusterConfigSpec) error {
+		i, err := nodeGroupIndex(eksConfig, nodeGroupName)
+		if err != nil {
+			return err
+		}
+		return updateFunc(&eksConfig.NodeGroups[i])
+	})
+}
+
+// ScaleNodeGroupFields are the config and upstream spec fields changed by scaling, i.e. the desired, minimum and maximum sizes of the nodegroups
+var ScaleNodeGroupFields = []string{"nodeGroups[*].desiredSize", "nodeGroups[*].maxSize", "nodeGroups[*].minSize"}
+
+// NodeCountPerPool returns the number of nodes desired for each nodegroup of the cluster
+func NodeCountPerPool(cluster *management.Cluster) map[string]int64 {
+	counts := map[string]int64{}
+	for _, ng := range cluster.EKSConfig.NodeGroups {
+		counts[*ng.NodegroupName] = *ng.DesiredSize
+	}
+	return counts
+}
+
+// nodeGroupIndex returns the index of the nodegroup named nodeGroupName in the EKS config
+func nodeGroupIndex(eksConfig *management.EKSClusterConfigSpec, nodeGroupName string) (int, error) {
+	for i, ng := range eksConfig.NodeGroups {
+		if ng.NodegroupName != nil && *ng.NodegroupName == nodeGroupName {
+			return i, nil
+		}
+	}
+	return -1, errors.Errorf("nodegroup %s not found in cluster %s", nodeGroupName, eksConfig.DisplayName)
+}
+
 // ListEKSAvailableVersions is a function to list and return only available EKS versions for a specific cluster.
 func ListEKSAvailableVersions(client *rancher.Client, clusterID string) (availableVersions []string, err error) {
 	// kubernetesversions.ListEKSAvailableVersions expects cluster.Version.GitVersion to be available, which it is not sometimes, so we fetch the cluster again to ensure it has all the available data
@@ -133,6 +313,29 @@ func CreateEKSClusterOnAWS(eks_region string, clusterName string, k8sVersion str
 	return nil
 }
 
+// GetTagsOnAWS returns the tags of the EKS cluster using AWS CLI
+func GetTagsOnAWS(eks_region string, clusterName string) (map[string]string, error) {
+	out, err := proc.RunW("aws", "eks", "describe-cluster", "--region", eks_region, "--name", clusterName, "--query", "cluster.tags", "--output", "json")
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to describe cluster: "+out)
+	}
+
+	tags := map[string]string{}
+	if err = json.Unmarshal([]byte(out), &tags); err != nil {
+		return nil, errors.Wrap(err, "Failed to parse cluster tags: "+out)
+	}
+	return tags, nil
+}
+
+// GetKubeconfigOnAWS writes the kubeconfig of the EKS cluster to kubeconfigPath using AWS CLI
+func GetKubeconfigOnAWS(eks_region, clusterName, kubeconfigPath string) error {
+	out, err := proc.RunW("aws", "eks", "update-kubeconfig", "--region", eks_region, "--name", clusterName, "--kubeconfig", kubeconfigPath)
+	if err != nil {
+		return errors.Wrap(err, "Failed to update kubeconfig: "+out)
+	}
+	return nil
+}
+
 // Complete cleanup steps for Amazon EKS
 func DeleteEKSClusterOnAWS(eks_region string, clusterName string) error {
 
@@ -147,6 +350,69 @@ func DeleteEKSClusterOnAWS(eks_region string, clusterName string) error {
 	return nil
 }
 
+// Create an EC2 launch template for EKS nodegroups using AWS CLI and return its ID
+func CreateLaunchTemplateOnAWS(eks_region string, templateName string, instanceType string) (string, error) {
+
+	fmt.Println("Creating launch template ...")
+	out, err := proc.RunW("aws", "ec2", "create-launch-template", "--region", eks_region, "--launch-template-name", templateName, "--launch-template-data", fmt.Sprintf(`{"InstanceType":"%s"}`, instanceType), "--query", "LaunchTemplate.LaunchTemplateId", "--output", "text")
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to create launch template: "+out)
+	}
+	fmt.Println("Created launch template: ", templateName)
+
+	return strings.TrimSpace(out), nil
+}
+
+// Create a new version of the EC2 launch template using AWS CLI and return its version number;
+// the new version is based on the first one and requires IMDSv2, so that it differs from it
+func CreateLaunchTemplateVersionOnAWS(eks_region string, templateID string) (int64, error) {
+
+	fmt.Println("Creating launch template version ...")
+	out, err := proc.RunW("aws", "ec2", "create-launch-template-version", "--region", eks_region, "--launch-template-id", templateID, "--source-version", "1", "--launch-template-data", `{"MetadataOptions":{"HttpTokens":"required","HttpPutResponseHopLimit":2}}`, "--query", "LaunchTemplateVersion.VersionNumber", "--output", "text")
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to create launch template version: "+out)
+	}
+	version, err := strconv.ParseInt(strings.TrimSpace(out), 10, 64)
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to parse launch template version: "+out)
+	}
+	fmt.Println("Created launch template version: ", version)
+
+	return version, nil
+}
+
+// Delete the EC2 launch template using AWS CLI
+func DeleteLaunchTemplateOnAWS(eks_region string, templateID string) error {
+
+	fmt.Println("Deleting launch template ...")
+	out, err := proc.RunW("aws", "ec2", "delete-launch-template", "--region", eks_region, "--launch-template-id", templateID)
+	if err != nil {
+		return errors.Wrap(err, "Failed to delete launch template: "+out)
+	}
+
+	fmt.Println("Deleted launch template: ", templateID)
+
+	return nil
+}
+
+// WaitForPrivateRequiresTunnel waits until Rancher reports that the private-only cluster can only be reached through the cattle cluster agent;
+// on failure, the last observed cluster is returned along with the error
+func WaitForPrivateRequiresTunnel(client *rancher.Client, clusterID string) (*management.Cluster, error) {
+	var cluster *management.Cluster
+	err := kwait.Poll(10*time.Second, helpers.Timeout, func() (bool, error) {
+		currentCluster, err := client.Management.Cluster.ByID(clusterID)
+		if err != nil {
+			return false, nil
+		}
+		cluster = currentCluster
+		return cluster.EKSStatus != nil && pointer.BoolDeref(cluster.EKSStatus.PrivateRequiresTunnel, false), nil
+	})
+	if err != nil {
+		return cluster, errors.Wrap(err, "cluster "+clusterID+" was never reported as requiring a tunnel")
+	}
+	return cluster, nil
+}
+
 func ImportEKSHostedCluster(client *rancher.Client, displayName, cloudCredentialID string, enableClusterAlerting, enableClusterMonitoring, enableNetworkPolicy, windowsPreferedCluster bool, labels map[string]string) (*management.Cluster, error) {
 	eksHostCluster := EksHostClusterConfig(displayName, cloudCredentialID)
 	cluster := &management.Cluster{
@@ -191,3 +457,12 @@ type ImportClusterConfig struct {
 	Imported   bool                    `json:"imported" yaml:"imported"`
 	NodeGroups []*management.NodeGroup `json:"nodeGroups" yaml:"nodeGroups"`
 }
+
+// NetworkingConfig is the configuration of the pre-existing subnets and security groups used to provision a cluster with bring-your-own networking
+type NetworkingConfig struct {
+	Subnets        []string `json:"subnets" yaml:"subnets"`
+	SecurityGroups []string `json:"securityGroups" yaml:"securityGroups"`
+}
+
+// NetworkingConfigConfigurationFileKey is the json/yaml config key for NetworkingConfig
+const NetworkingConfigConfigurationFileKey = "eksNetworkingConfig"
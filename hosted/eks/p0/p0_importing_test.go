@@ -37,8 +37,8 @@ var _ = Describe("P0Importing", func() {
 			Expect(err).To(BeNil())
 			cluster, err = helpers.WaitUntilClusterIsReady(cluster, ctx.RancherClient)
 			Expect(err).To(BeNil())
-			// Workaround to add new Nodegroup till https://github.com/rancher/aks-operator/issues/251 is fixed
-			cluster.EKSConfig = cluster.EKSStatus.UpstreamSpec
+			cluster, err = helper.AdoptUpstreamSpec(cluster, ctx.RancherClient)
+			Expect(err).To(BeNil())
 		})
 		AfterEach(func() {
 			err := helper.DeleteEKSHostCluster(cluster, ctx.RancherClient)
@@ -70,6 +70,11 @@ var _ = Describe("P0Importing", func() {
 				Expect(podErrors).To(BeEmpty())
 			})
 
+			By("checking the cluster directly through its kubeconfig", func() {
+				err := helpers.VerifyClusterThroughKubeconfig(ctx.RancherClient, cluster.ID, *cluster.EKSConfig.KubernetesVersion)
+				Expect(err).To(BeNil())
+			})
+
 		})
 
 		Context("Upgrading K8s version", func() {
@@ -86,7 +91,9 @@ var _ = Describe("P0Importing", func() {
 					var err error
 					cluster, err = helper.UpgradeClusterKubernetesVersion(cluster, upgradeToVersion, ctx.RancherClient)
 					Expect(err).To(BeNil())
-					err = clusters.WaitClusterToBeUpgraded(ctx.RancherClient, cluster.ID)
+					cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+					Expect(err).To(BeNil())
+					_, err = helpers.WaitForClusterGitVersion(ctx.RancherClient, cluster.ID, *upgradeToVersion)
 					Expect(err).To(BeNil())
 					Expect(cluster.EKSConfig.KubernetesVersion).To(BeEquivalentTo(upgradeToVersion))
 				})
@@ -95,7 +102,9 @@ var _ = Describe("P0Importing", func() {
 					var err error
 					cluster, err = helper.UpgradeNodeKubernetesVersion(cluster, upgradeToVersion, ctx.RancherClient)
 					Expect(err).To(BeNil())
-					err = clusters.WaitClusterToBeUpgraded(ctx.RancherClient, cluster.ID)
+					cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+					Expect(err).To(BeNil())
+					err = helpers.WaitForNodePoolsKubeletVersion(ctx.RancherClient, cluster.ID, helper.NodeGroupLabel, *upgradeToVersion)
 					Expect(err).To(BeNil())
 					for _, ng := range cluster.EKSConfig.NodeGroups {
 						Expect(ng.Version).To(BeEquivalentTo(upgradeToVersion))
@@ -111,7 +120,7 @@ var _ = Describe("P0Importing", func() {
 				var err error
 				cluster, err = helper.AddNodeGroup(cluster, increaseBy, ctx.RancherClient)
 				Expect(err).To(BeNil())
-				err = clusters.WaitClusterToBeUpgraded(ctx.RancherClient, cluster.ID)
+				cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
 				Expect(err).To(BeNil())
 				Expect(len(cluster.EKSConfig.NodeGroups)).To(BeNumerically("==", currentNodeGroupNumber+1))
 			})
@@ -119,7 +128,7 @@ var _ = Describe("P0Importing", func() {
 				var err error
 				cluster, err = helper.DeleteNodeGroup(cluster, ctx.RancherClient)
 				Expect(err).To(BeNil())
-				err = clusters.WaitClusterToBeUpgraded(ctx.RancherClient, cluster.ID)
+				cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
 				Expect(err).To(BeNil())
 				Expect(len(cluster.EKSConfig.NodeGroups)).To(BeNumerically("==", currentNodeGroupNumber))
 
@@ -130,22 +139,36 @@ var _ = Describe("P0Importing", func() {
 			initialNodeCount := *cluster.EKSConfig.NodeGroups[0].DesiredSize
 
 			By("scaling up the NodeGroup", func() {
-				var err error
+				snapshot, err := helpers.TakeClusterSnapshot(ctx.RancherClient, cluster.ID)
+				Expect(err).To(BeNil())
 				cluster, err = helper.ScaleNodeGroup(cluster, ctx.RancherClient, initialNodeCount+1)
 				Expect(err).To(BeNil())
-				err = clusters.WaitClusterToBeUpgraded(ctx.RancherClient, cluster.ID)
+				cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+				Expect(err).To(BeNil())
+				err = helpers.WaitForNodeCountPerPool(ctx.RancherClient, cluster.ID, helper.NodeGroupLabel, helper.NodeCountPerPool(cluster), true)
 				Expect(err).To(BeNil())
+				diff, err := helpers.DiffClusterSince(ctx.RancherClient, cluster.ID, snapshot)
+				Expect(err).To(BeNil())
+				Expect(diff.Config.Except(helper.ScaleNodeGroupFields...)).To(BeEmpty())
+				Expect(diff.UpstreamSpec.Except(helper.ScaleNodeGroupFields...)).To(BeEmpty())
 				for i := range cluster.EKSConfig.NodeGroups {
 					Expect(*cluster.EKSConfig.NodeGroups[i].DesiredSize).To(BeNumerically("==", initialNodeCount+1))
 				}
 			})
 
 			By("scaling down the NodeGroup", func() {
-				var err error
+				snapshot, err := helpers.TakeClusterSnapshot(ctx.RancherClient, cluster.ID)
+				Expect(err).To(BeNil())
 				cluster, err = helper.ScaleNodeGroup(cluster, ctx.RancherClient, initialNodeCount)
 				Expect(err).To(BeNil())
-				err = clusters.WaitClusterToBeUpgraded(ctx.RancherClient, cluster.ID)
+				cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+				Expect(err).To(BeNil())
+				err = helpers.WaitForNodeCountPerPool(ctx.RancherClient, cluster.ID, helper.NodeGroupLabel, helper.NodeCountPerPool(cluster), true)
+				Expect(err).To(BeNil())
+				diff, err := helpers.DiffClusterSince(ctx.RancherClient, cluster.ID, snapshot)
 				Expect(err).To(BeNil())
+				Expect(diff.Config.Except(helper.ScaleNodeGroupFields...)).To(BeEmpty())
+				Expect(diff.UpstreamSpec.Except(helper.ScaleNodeGroupFields...)).To(BeEmpty())
 				for i := range cluster.EKSConfig.NodeGroups {
 					Expect(*cluster.EKSConfig.NodeGroups[i].DesiredSize).To(BeNumerically("==", initialNodeCount))
 				}
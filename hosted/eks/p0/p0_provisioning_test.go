@@ -9,11 +9,25 @@ import (
 	nodestat "github.com/rancher/rancher/tests/framework/extensions/nodes"
 	"github.com/rancher/rancher/tests/framework/extensions/workloads/pods"
 	namegen "github.com/rancher/rancher/tests/framework/pkg/namegenerator"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/pointer"
 
 	"github.com/valaparthvi/highlander-tests/hosted/eks/helper"
 	"github.com/valaparthvi/highlander-tests/hosted/helpers"
 )
 
+// userData is a MIME multi-part user data, as EKS requires for launch templates of managed nodegroups
+const userData = `MIME-Version: 1.0
+Content-Type: multipart/mixed; boundary="==BOUNDARY=="
+
+--==BOUNDARY==
+Content-Type: text/x-shellscript; charset="us-ascii"
+
+#!/bin/bash
+echo "highlander" > /tmp/highlander
+
+--==BOUNDARY==--`
+
 var _ = Describe("P0Provisioning", func() {
 	var (
 		clusterName string
@@ -32,13 +46,30 @@ var _ = Describe("P0Provisioning", func() {
 			var err error
 			cluster, err = eks.CreateEKSHostedCluster(ctx.RancherClient, clusterName, ctx.CloudCred.ID, false, false, false, false, map[string]string{})
 			Expect(err).To(BeNil())
+			// the cluster is deleted by a cleanup rather than an AfterEach, so that the cleanups registered by the specs,
+			// such as the removal of their persistent volumes, run while the cluster still exists
+			DeferCleanup(func() {
+				err := helper.DeleteEKSHostCluster(cluster, ctx.RancherClient)
+				Expect(err).To(BeNil())
+			})
 			cluster, err = helpers.WaitUntilClusterIsReady(cluster, ctx.RancherClient)
 			Expect(err).To(BeNil())
 		})
-		AfterEach(func() {
-			err := helper.DeleteEKSHostCluster(cluster, ctx.RancherClient)
+
+		// writeVolumeData writes data to a persistent volume, which is removed once the spec is done;
+		// EKS can only provision volumes with the EBS CSI driver add-on, so without it nil is returned and the skipped check is reported
+		writeVolumeData := func(clientset *kubernetes.Clientset) *helpers.VolumeData {
+			hasDriver, err := helpers.HasCSIDriver(clientset, helper.EBSCSIDriverName)
 			Expect(err).To(BeNil())
-		})
+			if !hasDriver {
+				AddReportEntry("persistent volume data not checked", "the EBS CSI driver add-on is not installed in cluster "+clusterName)
+				return nil
+			}
+			volumeData, err := helpers.WriteVolumeData(clientset)
+			Expect(err).To(BeNil())
+			DeferCleanup(helpers.DeleteVolumeData, clientset, volumeData)
+			return volumeData
+		}
 
 		It("should successfully provision the cluster", func() {
 
@@ -62,6 +93,16 @@ var _ = Describe("P0Provisioning", func() {
 				Expect(podErrors).To(BeEmpty())
 			})
 
+			By("checking the cluster directly through its kubeconfig", func() {
+				err := helpers.VerifyClusterThroughKubeconfig(ctx.RancherClient, cluster.ID, *cluster.EKSConfig.KubernetesVersion)
+				Expect(err).To(BeNil())
+			})
+
+			By("checking a LoadBalancer service is reachable", func() {
+				err := helpers.VerifyLoadBalancerService(ctx.RancherClient, cluster.ID)
+				Expect(err).To(BeNil())
+			})
+
 		})
 
 		Context("Upgrading K8s version", func() {
@@ -74,25 +115,56 @@ var _ = Describe("P0Provisioning", func() {
 			})
 
 			It("should be able to upgrade k8s version of the cluster", func() {
+				var (
+					clientset  *kubernetes.Clientset
+					volumeData *helpers.VolumeData
+				)
+
 				By("upgrading the ControlPlane", func() {
 					var err error
 					cluster, err = helper.UpgradeClusterKubernetesVersion(cluster, upgradeToVersion, ctx.RancherClient)
 					Expect(err).To(BeNil())
-					err = clusters.WaitClusterToBeUpgraded(ctx.RancherClient, cluster.ID)
+					cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+					Expect(err).To(BeNil())
+					_, err = helpers.WaitForClusterGitVersion(ctx.RancherClient, cluster.ID, *upgradeToVersion)
 					Expect(err).To(BeNil())
 					Expect(cluster.EKSConfig.KubernetesVersion).To(BeEquivalentTo(upgradeToVersion))
 				})
 
+				By("writing data to a persistent volume", func() {
+					var err error
+					clientset, err = helpers.GetDownstreamClientset(ctx.RancherClient, cluster.ID)
+					Expect(err).To(BeNil())
+					volumeData = writeVolumeData(clientset)
+				})
+
 				By("upgrading the NodeGroups", func() {
 					var err error
 					cluster, err = helper.UpgradeNodeKubernetesVersion(cluster, upgradeToVersion, ctx.RancherClient)
 					Expect(err).To(BeNil())
-					err = clusters.WaitClusterToBeUpgraded(ctx.RancherClient, cluster.ID)
+					cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+					Expect(err).To(BeNil())
+					err = helpers.WaitForNodePoolsKubeletVersion(ctx.RancherClient, cluster.ID, helper.NodeGroupLabel, *upgradeToVersion)
 					Expect(err).To(BeNil())
 					for _, ng := range cluster.EKSConfig.NodeGroups {
 						Expect(ng.Version).To(BeEquivalentTo(upgradeToVersion))
 					}
 				})
+
+				By("verifying the persistent volume data from a rescheduled pod", func() {
+					if volumeData == nil {
+						return
+					}
+					reader, err := helpers.VerifyVolumeData(clientset, volumeData)
+					Expect(err).To(BeNil())
+					// the upgrade replaces every node of the NodeGroups, so the volume must have moved to a new node
+					Expect(reader).ToNot(Equal(volumeData.Writer))
+				})
+
+				By("checking a LoadBalancer service is reachable", func() {
+					err := helpers.VerifyLoadBalancerService(ctx.RancherClient, cluster.ID)
+					Expect(err).To(BeNil())
+				})
 			})
 		})
 
@@ -103,7 +175,7 @@ var _ = Describe("P0Provisioning", func() {
 				var err error
 				cluster, err = helper.AddNodeGroup(cluster, increaseBy, ctx.RancherClient)
 				Expect(err).To(BeNil())
-				err = clusters.WaitClusterToBeUpgraded(ctx.RancherClient, cluster.ID)
+				cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
 				Expect(err).To(BeNil())
 				Expect(len(cluster.EKSConfig.NodeGroups)).To(BeNumerically("==", currentNodeGroupNumber+1))
 			})
@@ -111,37 +183,316 @@ var _ = Describe("P0Provisioning", func() {
 				var err error
 				cluster, err = helper.DeleteNodeGroup(cluster, ctx.RancherClient)
 				Expect(err).To(BeNil())
-				err = clusters.WaitClusterToBeUpgraded(ctx.RancherClient, cluster.ID)
+				cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
 				Expect(err).To(BeNil())
 				Expect(len(cluster.EKSConfig.NodeGroups)).To(BeNumerically("==", currentNodeGroupNumber))
 
 			})
 
 		})
+		It("should be possible to add a NodeGroup with spot capacity", func() {
+			var nodeGroupName string
+
+			By("adding a spot NodeGroup", func() {
+				var err error
+				cluster, err = helper.AddSpotNodeGroup(cluster, increaseBy, ctx.RancherClient)
+				Expect(err).To(BeNil())
+				cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+				Expect(err).To(BeNil())
+				nodeGroupName = *cluster.EKSConfig.NodeGroups[len(cluster.EKSConfig.NodeGroups)-1].NodegroupName
+			})
+
+			By("checking the capacity type is reported by the upstream spec", func() {
+				Expect(cluster.EKSStatus.UpstreamSpec.NodeGroups).To(ContainElement(And(
+					HaveField("NodegroupName", Equal(&nodeGroupName)),
+					HaveField("RequestSpotInstances", Equal(pointer.Bool(true))),
+				)))
+			})
+
+			By("checking the nodes have the spot capacity type label", func() {
+				nodes, err := helpers.ListDownstreamNodes(ctx.RancherClient, cluster.ID, helper.NodeGroupLabel+"="+nodeGroupName)
+				Expect(err).To(BeNil())
+				Expect(nodes).ToNot(BeEmpty())
+				for _, node := range nodes {
+					Expect(node.Labels).To(HaveKeyWithValue(helper.CapacityTypeLabel, "SPOT"))
+				}
+			})
+		})
+
+		It("should be possible to add, update and remove the NodeGroup labels", func() {
+			var nodeGroupName string
+
+			By("adding a NodeGroup", func() {
+				var err error
+				cluster, err = helper.AddNodeGroup(cluster, increaseBy, ctx.RancherClient)
+				Expect(err).To(BeNil())
+				cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+				Expect(err).To(BeNil())
+				nodeGroupName = *cluster.EKSConfig.NodeGroups[len(cluster.EKSConfig.NodeGroups)-1].NodegroupName
+			})
+
+			By("adding labels to the NodeGroup", func() {
+				var err error
+				cluster, err = helper.UpdateNodeGroupLabels(cluster, ctx.RancherClient, nodeGroupName, map[string]string{"highlander": "add"})
+				Expect(err).To(BeNil())
+				cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+				Expect(err).To(BeNil())
+
+				nodes, err := helpers.ListDownstreamNodes(ctx.RancherClient, cluster.ID, helper.NodeGroupLabel+"="+nodeGroupName)
+				Expect(err).To(BeNil())
+				Expect(nodes).ToNot(BeEmpty())
+				for _, node := range nodes {
+					Expect(node.Labels).To(HaveKeyWithValue("highlander", "add"))
+				}
+			})
+
+			By("updating the NodeGroup labels", func() {
+				var err error
+				cluster, err = helper.UpdateNodeGroupLabels(cluster, ctx.RancherClient, nodeGroupName, map[string]string{"highlander": "update"})
+				Expect(err).To(BeNil())
+				cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+				Expect(err).To(BeNil())
+
+				nodes, err := helpers.ListDownstreamNodes(ctx.RancherClient, cluster.ID, helper.NodeGroupLabel+"="+nodeGroupName)
+				Expect(err).To(BeNil())
+				Expect(nodes).ToNot(BeEmpty())
+				for _, node := range nodes {
+					Expect(node.Labels).To(HaveKeyWithValue("highlander", "update"))
+				}
+			})
+
+			By("removing the NodeGroup labels", func() {
+				var err error
+				cluster, err = helper.UpdateNodeGroupLabels(cluster, ctx.RancherClient, nodeGroupName, map[string]string{})
+				Expect(err).To(BeNil())
+				cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+				Expect(err).To(BeNil())
+
+				nodes, err := helpers.ListDownstreamNodes(ctx.RancherClient, cluster.ID, helper.NodeGroupLabel+"="+nodeGroupName)
+				Expect(err).To(BeNil())
+				Expect(nodes).ToNot(BeEmpty())
+				for _, node := range nodes {
+					Expect(node.Labels).ToNot(HaveKey("highlander"))
+				}
+			})
+		})
+
+		It("should be possible to add a NodeGroup with a custom AMI", func() {
+			nodeConfig := helper.EksHostNodeConfig()[0]
+			if nodeConfig.ImageID == nil || *nodeConfig.ImageID == "" {
+				Skip("imageId is not set in the eksClusterConfig nodeGroups")
+			}
+			imageID := *nodeConfig.ImageID
+			nodeUserData := pointer.StringDeref(nodeConfig.UserData, "")
+			ec2SshKey := pointer.StringDeref(nodeConfig.Ec2SshKey, "")
+
+			var err error
+			cluster, err = helper.AddNodeGroupWithCustomAMI(cluster, ctx.RancherClient, imageID, nodeUserData, ec2SshKey)
+			Expect(err).To(BeNil())
+			cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+			Expect(err).To(BeNil())
+			nodeGroupName := *cluster.EKSConfig.NodeGroups[len(cluster.EKSConfig.NodeGroups)-1].NodegroupName
+
+			Expect(cluster.EKSStatus.ManagedLaunchTemplateVersions).To(HaveKey(nodeGroupName))
+			upstreamNodeGroup := SatisfyAll(
+				HaveField("NodegroupName", HaveValue(Equal(nodeGroupName))),
+				HaveField("ImageID", HaveValue(Equal(imageID))),
+			)
+			if ec2SshKey != "" {
+				upstreamNodeGroup = SatisfyAll(upstreamNodeGroup, HaveField("Ec2SshKey", HaveValue(Equal(ec2SshKey))))
+			}
+			Expect(cluster.EKSStatus.UpstreamSpec.NodeGroups).To(ContainElement(upstreamNodeGroup))
+			err = nodestat.AllManagementNodeReady(ctx.RancherClient, cluster.ID, helpers.Timeout)
+			Expect(err).To(BeNil())
+		})
+
+		It("should be possible to update the Rancher-managed launch template of a NodeGroup", func() {
+			var nodeGroupName, launchTemplateVersion string
+			ec2SshKey := pointer.StringDeref(helper.EksHostNodeConfig()[0].Ec2SshKey, "")
+
+			By("adding a NodeGroup", func() {
+				var err error
+				cluster, err = helper.AddNodeGroupWithCustomAMI(cluster, ctx.RancherClient, "", "", ec2SshKey)
+				Expect(err).To(BeNil())
+				cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+				Expect(err).To(BeNil())
+				nodeGroupName = *cluster.EKSConfig.NodeGroups[len(cluster.EKSConfig.NodeGroups)-1].NodegroupName
+
+				Expect(cluster.EKSStatus.ManagedLaunchTemplateID).ToNot(BeEmpty())
+				Expect(cluster.EKSStatus.ManagedLaunchTemplateVersions).To(HaveKey(nodeGroupName))
+				launchTemplateVersion = cluster.EKSStatus.ManagedLaunchTemplateVersions[nodeGroupName]
+			})
+
+			By("updating the user data of the NodeGroup", func() {
+				var err error
+				cluster, err = helper.UpdateNodeGroupUserData(cluster, ctx.RancherClient, nodeGroupName, userData)
+				Expect(err).To(BeNil())
+				cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+				Expect(err).To(BeNil())
+
+				Expect(cluster.EKSStatus.ManagedLaunchTemplateVersions).To(HaveKey(nodeGroupName))
+				Expect(cluster.EKSStatus.ManagedLaunchTemplateVersions[nodeGroupName]).ToNot(Equal(launchTemplateVersion))
+			})
+		})
+
+		It("should be possible to use a user-supplied launch template and update its version", func() {
+			var nodeGroupName, launchTemplateID string
+			region := cluster.EKSConfig.Region
+
+			By("creating a launch template", func() {
+				var err error
+				launchTemplateID, err = helper.CreateLaunchTemplateOnAWS(region, namegen.AppendRandomString("highlander"), *helper.EksHostNodeConfig()[0].InstanceType)
+				Expect(err).To(BeNil())
+				DeferCleanup(func() {
+					err := helper.DeleteLaunchTemplateOnAWS(region, launchTemplateID)
+					Expect(err).To(BeNil())
+				})
+			})
+
+			By("adding a NodeGroup using the launch template", func() {
+				var err error
+				cluster, err = helper.AddNodeGroupWithLaunchTemplate(cluster, ctx.RancherClient, management.LaunchTemplate{ID: &launchTemplateID, Version: pointer.Int64(1)})
+				Expect(err).To(BeNil())
+				cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+				Expect(err).To(BeNil())
+				nodeGroupName = *cluster.EKSConfig.NodeGroups[len(cluster.EKSConfig.NodeGroups)-1].NodegroupName
+			})
+
+			By("updating the launch template version of the NodeGroup", func() {
+				version, err := helper.CreateLaunchTemplateVersionOnAWS(region, launchTemplateID)
+				Expect(err).To(BeNil())
+				cluster, err = helper.UpdateNodeGroupLaunchTemplateVersion(cluster, ctx.RancherClient, nodeGroupName, version)
+				Expect(err).To(BeNil())
+				cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+				Expect(err).To(BeNil())
+
+				Expect(cluster.EKSStatus.UpstreamSpec.NodeGroups).To(ContainElement(SatisfyAll(
+					HaveField("NodegroupName", HaveValue(Equal(nodeGroupName))),
+					HaveField("LaunchTemplate.ID", HaveValue(Equal(launchTemplateID))),
+					HaveField("LaunchTemplate.Version", HaveValue(Equal(version))),
+				)))
+			})
+		})
+
+		It("should be possible to add, update and remove the cluster labels and tags", func() {
+			region := cluster.EKSConfig.Region
+
+			By("adding cluster labels and tags", func() {
+				var err error
+				cluster, err = helpers.UpdateClusterLabels(cluster, ctx.RancherClient, map[string]string{"highlander": "add"}, nil)
+				Expect(err).To(BeNil())
+				cluster, err = helper.UpdateClusterTags(cluster, ctx.RancherClient, map[string]string{"highlander": "add"})
+				Expect(err).To(BeNil())
+				cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+				Expect(err).To(BeNil())
+				Expect(cluster.Labels).To(HaveKeyWithValue("highlander", "add"))
+
+				tags, err := helper.GetTagsOnAWS(region, clusterName)
+				Expect(err).To(BeNil())
+				Expect(tags).To(HaveKeyWithValue("highlander", "add"))
+			})
+
+			By("updating cluster labels and tags", func() {
+				var err error
+				cluster, err = helpers.UpdateClusterLabels(cluster, ctx.RancherClient, map[string]string{"highlander": "update"}, nil)
+				Expect(err).To(BeNil())
+				cluster, err = helper.UpdateClusterTags(cluster, ctx.RancherClient, map[string]string{"highlander": "update"})
+				Expect(err).To(BeNil())
+				cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+				Expect(err).To(BeNil())
+				Expect(cluster.Labels).To(HaveKeyWithValue("highlander", "update"))
+
+				tags, err := helper.GetTagsOnAWS(region, clusterName)
+				Expect(err).To(BeNil())
+				Expect(tags).To(HaveKeyWithValue("highlander", "update"))
+			})
+
+			By("removing cluster labels and tags", func() {
+				var err error
+				cluster, err = helpers.UpdateClusterLabels(cluster, ctx.RancherClient, nil, []string{"highlander"})
+				Expect(err).To(BeNil())
+				cluster, err = helper.UpdateClusterTags(cluster, ctx.RancherClient, map[string]string{})
+				Expect(err).To(BeNil())
+				cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+				Expect(err).To(BeNil())
+				Expect(cluster.Labels).ToNot(HaveKey("highlander"))
+
+				tags, err := helper.GetTagsOnAWS(region, clusterName)
+				Expect(err).To(BeNil())
+				Expect(tags).ToNot(HaveKey("highlander"))
+			})
+		})
+
+		It("should be possible to install, upgrade and uninstall a catalog app", func() {
+			helpers.VerifyCatalogAppLifecycle(ctx.RancherClient, cluster.ID)
+		})
+
 		It("should be possible to scale up/down the NodeGroup", func() {
+			var (
+				clientset  *kubernetes.Clientset
+				volumeData *helpers.VolumeData
+			)
+
 			initialNodeCount := *cluster.EKSConfig.NodeGroups[0].DesiredSize
 
 			By("scaling up the NodeGroup", func() {
-				var err error
+				snapshot, err := helpers.TakeClusterSnapshot(ctx.RancherClient, cluster.ID)
+				Expect(err).To(BeNil())
 				cluster, err = helper.ScaleNodeGroup(cluster, ctx.RancherClient, initialNodeCount+1)
 				Expect(err).To(BeNil())
-				err = clusters.WaitClusterToBeUpgraded(ctx.RancherClient, cluster.ID)
+				cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+				Expect(err).To(BeNil())
+				err = helpers.WaitForNodeCountPerPool(ctx.RancherClient, cluster.ID, helper.NodeGroupLabel, helper.NodeCountPerPool(cluster), true)
 				Expect(err).To(BeNil())
+				diff, err := helpers.DiffClusterSince(ctx.RancherClient, cluster.ID, snapshot)
+				Expect(err).To(BeNil())
+				Expect(diff.Config.Except(helper.ScaleNodeGroupFields...)).To(BeEmpty())
+				Expect(diff.UpstreamSpec.Except(helper.ScaleNodeGroupFields...)).To(BeEmpty())
 				for i := range cluster.EKSConfig.NodeGroups {
 					Expect(*cluster.EKSConfig.NodeGroups[i].DesiredSize).To(BeNumerically("==", initialNodeCount+1))
 				}
 			})
 
-			By("scaling down the NodeGroup", func() {
+			By("writing data to a persistent volume", func() {
 				var err error
+				clientset, err = helpers.GetDownstreamClientset(ctx.RancherClient, cluster.ID)
+				Expect(err).To(BeNil())
+				volumeData = writeVolumeData(clientset)
+			})
+
+			By("scaling down the NodeGroup", func() {
+				snapshot, err := helpers.TakeClusterSnapshot(ctx.RancherClient, cluster.ID)
+				Expect(err).To(BeNil())
 				cluster, err = helper.ScaleNodeGroup(cluster, ctx.RancherClient, initialNodeCount)
 				Expect(err).To(BeNil())
-				err = clusters.WaitClusterToBeUpgraded(ctx.RancherClient, cluster.ID)
+				cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+				Expect(err).To(BeNil())
+				err = helpers.WaitForNodeCountPerPool(ctx.RancherClient, cluster.ID, helper.NodeGroupLabel, helper.NodeCountPerPool(cluster), true)
 				Expect(err).To(BeNil())
+				diff, err := helpers.DiffClusterSince(ctx.RancherClient, cluster.ID, snapshot)
+				Expect(err).To(BeNil())
+				Expect(diff.Config.Except(helper.ScaleNodeGroupFields...)).To(BeEmpty())
+				Expect(diff.UpstreamSpec.Except(helper.ScaleNodeGroupFields...)).To(BeEmpty())
 				for i := range cluster.EKSConfig.NodeGroups {
 					Expect(*cluster.EKSConfig.NodeGroups[i].DesiredSize).To(BeNumerically("==", initialNodeCount))
 				}
 			})
+
+			By("verifying the persistent volume data from a rescheduled pod", func() {
+				if volumeData == nil {
+					return
+				}
+				reader, err := helpers.VerifyVolumeData(clientset, volumeData)
+				Expect(err).To(BeNil())
+				// the NodeGroup picks the node to remove, so the volume only has to move if its node was the one removed
+				writerGone, err := helpers.IsVolumeNodeGone(clientset, volumeData.Writer)
+				Expect(err).To(BeNil())
+				if writerGone {
+					Expect(reader).ToNot(Equal(volumeData.Writer))
+				} else {
+					AddReportEntry("persistent volume not rescheduled", "the scale down kept node "+volumeData.Writer.Name+", which wrote the data")
+				}
+			})
 		})
 
 	})
@@ -0,0 +1,71 @@
+package p1_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	management "github.com/rancher/rancher/tests/framework/clients/rancher/generated/management/v3"
+	"github.com/rancher/rancher/tests/framework/extensions/clusters/eks"
+	namegen "github.com/rancher/rancher/tests/framework/pkg/namegenerator"
+
+	"github.com/valaparthvi/highlander-tests/hosted/eks/helper"
+	"github.com/valaparthvi/highlander-tests/hosted/helpers"
+)
+
+var _ = Describe("P1ClusterFeatures", func() {
+	var (
+		clusterName string
+		ctx         helpers.Context
+		cluster     *management.Cluster
+	)
+	var _ = BeforeEach(func() {
+		clusterName = namegen.AppendRandomString("ekshostcluster")
+		ctx = helpers.CommonBeforeSuite("eks")
+		cluster = nil
+	})
+	AfterEach(func() {
+		if cluster == nil {
+			return
+		}
+		err := helper.DeleteEKSHostCluster(cluster, ctx.RancherClient)
+		Expect(err).To(BeNil())
+	})
+
+	// project network isolation is not covered, EKS clusters have no network policy engine by default and Rancher rejects enableNetworkPolicy on them
+	DescribeTable("a cluster is created with a cluster feature enabled",
+		func(enableClusterAlerting, enableClusterMonitoring bool) {
+			if enableClusterAlerting || enableClusterMonitoring {
+				legacyEnabled, err := helpers.IsFeatureEnabled(ctx.RancherClient, helpers.LegacyFeature)
+				Expect(err).To(BeNil())
+				if !legacyEnabled {
+					Skip("monitoring v1 and alerting v1 require the " + helpers.LegacyFeature + " feature flag, which is disabled")
+				}
+			}
+
+			var err error
+			cluster, err = eks.CreateEKSHostedCluster(ctx.RancherClient, clusterName, ctx.CloudCred.ID, enableClusterAlerting, enableClusterMonitoring, false, false, map[string]string{})
+			Expect(err).To(BeNil())
+			cluster, err = helpers.WaitUntilClusterIsReady(cluster, ctx.RancherClient)
+			Expect(err).To(BeNil())
+
+			if enableClusterMonitoring {
+				By("checking the monitoring components are deployed", func() {
+					err := helpers.WaitForClusterCondition(ctx.RancherClient, cluster.ID, helpers.MonitoringEnabledCondition)
+					Expect(err).To(BeNil())
+					clientset, err := helpers.GetDownstreamClientset(ctx.RancherClient, cluster.ID)
+					Expect(err).To(BeNil())
+					err = helpers.WaitForNamespacePodsToBeReady(clientset, helpers.MonitoringV1Namespace)
+					Expect(err).To(BeNil())
+				})
+			}
+
+			if enableClusterAlerting {
+				By("checking the alerting components are deployed", func() {
+					err := helpers.WaitForClusterCondition(ctx.RancherClient, cluster.ID, helpers.AlertingEnabledCondition)
+					Expect(err).To(BeNil())
+				})
+			}
+		},
+		Entry("cluster monitoring", false, true),
+		Entry("cluster alerting", true, false),
+	)
+})
@@ -0,0 +1,168 @@
+package p1_test
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	management "github.com/rancher/rancher/tests/framework/clients/rancher/generated/management/v3"
+	"github.com/rancher/rancher/tests/framework/extensions/clusters"
+	"github.com/rancher/rancher/tests/framework/extensions/clusters/eks"
+	nodestat "github.com/rancher/rancher/tests/framework/extensions/nodes"
+	"github.com/rancher/rancher/tests/framework/extensions/workloads/pods"
+	"github.com/rancher/rancher/tests/framework/pkg/config"
+	namegen "github.com/rancher/rancher/tests/framework/pkg/namegenerator"
+	"k8s.io/utils/pointer"
+
+	"github.com/valaparthvi/highlander-tests/hosted/eks/helper"
+	"github.com/valaparthvi/highlander-tests/hosted/helpers"
+)
+
+var _ = Describe("P1Networking", func() {
+	var (
+		clusterName string
+		ctx         helpers.Context
+		cluster     *management.Cluster
+	)
+	var _ = BeforeEach(func() {
+		clusterName = namegen.AppendRandomString("ekshostcluster")
+		ctx = helpers.CommonBeforeSuite("eks")
+		cluster = nil
+		helpers.PreserveConfig()
+	})
+	AfterEach(func() {
+		if cluster == nil {
+			return
+		}
+		err := helper.DeleteEKSHostCluster(cluster, ctx.RancherClient)
+		Expect(err).To(BeNil())
+	})
+
+	// checkClusterIsReachable checks that Rancher reaches the cluster through the cattle cluster agent
+	checkClusterIsReachable := func() {
+		By("checking the cattle cluster agent is connected", func() {
+			Expect(helpers.IsClusterConnected(cluster)).To(BeTrue())
+			success, err := clusters.CheckServiceAccountTokenSecret(ctx.RancherClient, clusterName)
+			Expect(err).To(BeNil())
+			Expect(success).To(BeTrue())
+		})
+
+		By("checking all management nodes are ready", func() {
+			err := nodestat.AllManagementNodeReady(ctx.RancherClient, cluster.ID, helpers.Timeout)
+			Expect(err).To(BeNil())
+		})
+
+		By("checking all pods are ready", func() {
+			podErrors := pods.StatusPods(ctx.RancherClient, cluster.ID)
+			Expect(podErrors).To(BeEmpty())
+		})
+	}
+
+	DescribeTable("a cluster is created with API endpoint access",
+		func(publicAccess, privateAccess, restrictPublicAccessSources bool) {
+			publicAccessSources := []string{}
+			if restrictPublicAccessSources {
+				// PUBLIC_IP is the public IP of the Rancher server, as set by the CI workflow
+				publicIP := os.Getenv("PUBLIC_IP")
+				if publicIP == "" {
+					Skip("PUBLIC_IP is not set; public access cannot be restricted to the Rancher server")
+				}
+				publicAccessSources = []string{publicIP + "/32"}
+			}
+
+			var err error
+			eksConfig := new(eks.ClusterConfig)
+			config.LoadAndUpdateConfig(eks.EKSClusterConfigConfigurationFileKey, eksConfig, func() {
+				eksConfig.PublicAccess = &publicAccess
+				eksConfig.PrivateAccess = &privateAccess
+				eksConfig.PublicAccessSources = publicAccessSources
+			})
+			cluster, err = eks.CreateEKSHostedCluster(ctx.RancherClient, clusterName, ctx.CloudCred.ID, false, false, false, false, map[string]string{})
+			Expect(err).To(BeNil())
+			cluster, err = helpers.WaitUntilClusterIsReady(cluster, ctx.RancherClient)
+			Expect(err).To(BeNil())
+
+			By("checking the endpoint access is reported by the upstream spec", func() {
+				upstreamSpec := cluster.EKSStatus.UpstreamSpec
+				Expect(upstreamSpec.PublicAccess).To(BeEquivalentTo(&publicAccess))
+				Expect(upstreamSpec.PrivateAccess).To(BeEquivalentTo(&privateAccess))
+				if restrictPublicAccessSources {
+					Expect(*upstreamSpec.PublicAccessSources).To(ConsistOf(publicAccessSources))
+				}
+			})
+
+			checkClusterIsReachable()
+		},
+		Entry("public only", true, false, false),
+		Entry("public and private", true, true, false),
+		Entry("public only with restricted public access sources", true, false, true),
+		Entry("public and private with restricted public access sources", true, true, true),
+	)
+
+	It("should be reachable when created with bring-your-own subnets and security groups", func() {
+		networkingConfig := new(helper.NetworkingConfig)
+		config.LoadConfig(helper.NetworkingConfigConfigurationFileKey, networkingConfig)
+		if len(networkingConfig.Subnets) == 0 {
+			Skip("subnets are not set in the " + helper.NetworkingConfigConfigurationFileKey + " config")
+		}
+
+		var err error
+		eksConfig := new(eks.ClusterConfig)
+		config.LoadAndUpdateConfig(eks.EKSClusterConfigConfigurationFileKey, eksConfig, func() {
+			eksConfig.Subnets = networkingConfig.Subnets
+			eksConfig.SecurityGroups = networkingConfig.SecurityGroups
+		})
+		cluster, err = eks.CreateEKSHostedCluster(ctx.RancherClient, clusterName, ctx.CloudCred.ID, false, false, false, false, map[string]string{})
+		Expect(err).To(BeNil())
+		cluster, err = helpers.WaitUntilClusterIsReady(cluster, ctx.RancherClient)
+		Expect(err).To(BeNil())
+
+		By("checking the subnets and security groups are reported by the upstream spec", func() {
+			Expect(*cluster.EKSStatus.UpstreamSpec.Subnets).To(ConsistOf(networkingConfig.Subnets))
+			Expect(*cluster.EKSStatus.UpstreamSpec.SecurityGroups).To(ConsistOf(networkingConfig.SecurityGroups))
+		})
+
+		checkClusterIsReachable()
+	})
+
+	// the private API endpoint is only reachable from within the VPC of the cluster, which the test runner is expected to be part of
+	It("should be reachable through the cattle cluster agent when created with private-only access", func() {
+		var err error
+		eksConfig := new(eks.ClusterConfig)
+		config.LoadAndUpdateConfig(eks.EKSClusterConfigConfigurationFileKey, eksConfig, func() {
+			eksConfig.PublicAccess = pointer.Bool(false)
+			eksConfig.PrivateAccess = pointer.Bool(true)
+			eksConfig.PublicAccessSources = []string{}
+		})
+		cluster, err = eks.CreateEKSHostedCluster(ctx.RancherClient, clusterName, ctx.CloudCred.ID, false, false, false, false, map[string]string{})
+		Expect(err).To(BeNil())
+
+		By("checking Rancher reports the cluster requires a tunnel", func() {
+			var err error
+			cluster, err = helper.WaitForPrivateRequiresTunnel(ctx.RancherClient, cluster.ID)
+			Expect(err).To(BeNil())
+			// Rancher cannot reach the private API endpoint, so the cluster is not ready until the agent is deployed from within the VPC
+			Expect(helpers.IsClusterConnected(cluster)).To(BeFalse())
+		})
+
+		By("checking the registration command to deploy the cattle cluster agent is available", func() {
+			token, err := helpers.GetClusterRegistrationToken(ctx.RancherClient, cluster.ID)
+			Expect(err).To(BeNil())
+			Expect(token.Command).ToNot(BeEmpty())
+			Expect(token.ManifestURL).ToNot(BeEmpty())
+		})
+
+		By("deploying the cattle cluster agent from within the VPC", func() {
+			kubeconfigPath := filepath.Join(GinkgoT().TempDir(), "kubeconfig")
+			err := helper.GetKubeconfigOnAWS(cluster.EKSConfig.Region, clusterName, kubeconfigPath)
+			Expect(err).To(BeNil())
+			err = helpers.RegisterExistingCluster(ctx.RancherClient, cluster.ID, kubeconfigPath)
+			Expect(err).To(BeNil())
+			cluster, err = helpers.WaitUntilClusterIsReady(cluster, ctx.RancherClient)
+			Expect(err).To(BeNil())
+		})
+
+		checkClusterIsReachable()
+	})
+})
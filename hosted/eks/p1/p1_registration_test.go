@@ -0,0 +1,105 @@
+package p1_test
+
+import (
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	management "github.com/rancher/rancher/tests/framework/clients/rancher/generated/management/v3"
+	"github.com/rancher/rancher/tests/framework/extensions/workloads/pods"
+	namegen "github.com/rancher/rancher/tests/framework/pkg/namegenerator"
+
+	"github.com/valaparthvi/highlander-tests/hosted/eks/helper"
+	"github.com/valaparthvi/highlander-tests/hosted/helpers"
+)
+
+var _ = Describe("P1Registration", func() {
+	var (
+		ctx         helpers.Context
+		clusterName string
+		region      = "us-west-2"
+		k8sVersion  = "1.26"
+	)
+	var _ = BeforeEach(func() {
+		clusterName = namegen.AppendRandomString("ekshostcluster")
+		ctx = helpers.CommonBeforeSuite("eks")
+	})
+
+	When("an existing EKS cluster is imported through both import paths", func() {
+		var cluster *management.Cluster
+
+		BeforeEach(func() {
+			cluster = nil
+			err := helper.CreateEKSClusterOnAWS(region, clusterName, k8sVersion, "1")
+			Expect(err).To(BeNil())
+		})
+		AfterEach(func() {
+			if cluster != nil {
+				err := helper.DeleteEKSHostCluster(cluster, ctx.RancherClient)
+				Expect(err).To(BeNil())
+			}
+			err := helper.DeleteEKSClusterOnAWS(region, clusterName)
+			Expect(err).To(BeNil())
+		})
+
+		It("should register the same cluster through the operator and the generic import paths", func() {
+			var (
+				gitVersion string
+				nodeNames  []string
+			)
+
+			By("importing the cluster through the EKS operator", func() {
+				var err error
+				cluster, err = helper.ImportEKSHostedCluster(ctx.RancherClient, clusterName, ctx.CloudCred.ID, false, false, false, false, map[string]string{})
+				Expect(err).To(BeNil())
+				cluster, err = helpers.WaitUntilClusterIsReady(cluster, ctx.RancherClient)
+				Expect(err).To(BeNil())
+				gitVersion = cluster.Version.GitVersion
+				nodeList, err := helpers.ListDownstreamNodes(ctx.RancherClient, cluster.ID, "")
+				Expect(err).To(BeNil())
+				for _, node := range nodeList {
+					nodeNames = append(nodeNames, node.Name)
+				}
+			})
+
+			By("removing the cluster imported through the EKS operator", func() {
+				err := helper.DeleteEKSHostCluster(cluster, ctx.RancherClient)
+				Expect(err).To(BeNil())
+				err = helpers.WaitUntilClusterIsDeleted(ctx.RancherClient, cluster.ID)
+				Expect(err).To(BeNil())
+				cluster = nil
+			})
+
+			By("registering the cluster through the generic import path", func() {
+				var err error
+				cluster, err = helpers.CreateGenericImportedCluster(ctx.RancherClient, namegen.AppendRandomString("ekshostcluster"))
+				Expect(err).To(BeNil())
+				kubeconfigPath := filepath.Join(GinkgoT().TempDir(), "kubeconfig")
+				err = helper.GetKubeconfigOnAWS(region, clusterName, kubeconfigPath)
+				Expect(err).To(BeNil())
+				err = helpers.RegisterExistingCluster(ctx.RancherClient, cluster.ID, kubeconfigPath)
+				Expect(err).To(BeNil())
+				cluster, err = helpers.WaitUntilClusterIsReady(cluster, ctx.RancherClient)
+				Expect(err).To(BeNil())
+			})
+
+			By("checking both import paths report the same cluster", func() {
+				Expect(cluster.Driver).To(Equal(helpers.GenericImportedDriver))
+				Expect(cluster.EKSConfig).To(BeNil())
+				Expect(cluster.Version.GitVersion).To(Equal(gitVersion))
+				nodeList, err := helpers.ListDownstreamNodes(ctx.RancherClient, cluster.ID, "")
+				Expect(err).To(BeNil())
+				var registeredNodeNames []string
+				for _, node := range nodeList {
+					registeredNodeNames = append(registeredNodeNames, node.Name)
+				}
+				Expect(registeredNodeNames).To(ConsistOf(nodeNames))
+			})
+
+			By("checking all pods are ready", func() {
+				podErrors := pods.StatusPods(ctx.RancherClient, cluster.ID)
+				Expect(podErrors).To(BeEmpty())
+			})
+		})
+	})
+})
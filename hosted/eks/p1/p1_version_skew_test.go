@@ -0,0 +1,37 @@
+package p1_test
+
+import (
+	management "github.com/rancher/rancher/tests/framework/clients/rancher/generated/management/v3"
+	"github.com/rancher/rancher/tests/framework/extensions/clusters/eks"
+	"github.com/rancher/rancher/tests/framework/extensions/clusters/kubernetesversions"
+	"github.com/rancher/rancher/tests/framework/pkg/config"
+	namegen "github.com/rancher/rancher/tests/framework/pkg/namegenerator"
+
+	"github.com/valaparthvi/highlander-tests/hosted/eks/helper"
+	"github.com/valaparthvi/highlander-tests/hosted/helpers"
+)
+
+var _ = helpers.DescribeVersionSkew(helpers.VersionSkewProvider{
+	Cloud: "eks",
+	ListVersions: func(ctx helpers.Context) ([]string, error) {
+		return kubernetesversions.ListEKSAllVersions(ctx.RancherClient)
+	},
+	CreateCluster: func(ctx helpers.Context, k8sVersion string) (*management.Cluster, error) {
+		clusterName := namegen.AppendRandomString("ekshostcluster")
+		helpers.PreserveConfig()
+		eksConfig := new(eks.ClusterConfig)
+		config.LoadAndUpdateConfig(eks.EKSClusterConfigConfigurationFileKey, eksConfig, func() {
+			eksConfig.KubernetesVersion = &k8sVersion
+		})
+		return eks.CreateEKSHostedCluster(ctx.RancherClient, clusterName, ctx.CloudCred.ID, false, false, false, false, map[string]string{})
+	},
+	DeleteCluster:       helper.DeleteEKSHostCluster,
+	UpgradeControlPlane: helper.UpgradeClusterKubernetesVersion,
+	UpgradeNodes:        helper.UpgradeNodeKubernetesVersion,
+	// EKS reports for e.g. "InvalidParameterException: Nodegroup Kubernetes version should be equal to or lower than the cluster kubernetes version"
+	NewerNodesRejection: `(?i)nodegroup kubernetes version .*(equal to or lower than|greater than) .*cluster`,
+	// for e.g. "InvalidRequestException: Nodegroup ng-1 must be updated to match cluster version 1.26 before updating cluster version"
+	NodeSkewRejection: `(?i)nodegroup \S+ must be updated to match cluster version \S+ before updating cluster version`,
+	// for e.g. "InvalidParameterException: Unsupported Kubernetes minor version update from 1.26 to 1.28"
+	SkippedMinorRejection: `(?i)unsupported kubernetes minor version update from \S+ to \S+`,
+})
@@ -1,9 +1,11 @@
 package helper
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
+	"strings"
 
-	"github.com/Masterminds/semver/v3"
 	"github.com/rancher/rancher/tests/framework/clients/rancher"
 	management "github.com/rancher/rancher/tests/framework/clients/rancher/generated/management/v3"
 	"github.com/rancher/rancher/tests/framework/extensions/clusters/kubernetesversions"
@@ -13,25 +15,37 @@ import (
 
 	"github.com/epinio/epinio/acceptance/helpers/proc"
 	"github.com/pkg/errors"
+
+	"github.com/valaparthvi/highlander-tests/hosted/helpers"
 )
 
+// NodePoolLabel is the label GKE adds to every node with the name of the nodepool it belongs to
+const NodePoolLabel = "cloud.google.com/gke-nodepool"
+
+// PreemptibleLabel is the label GKE adds to every node of a preemptible nodepool
+const PreemptibleLabel = "cloud.google.com/gke-preemptible"
+
 // UpgradeKubernetesVersion upgrades the k8s version to the value defined by upgradeToVersion; if upgradeNodePool is true, it also upgrades nodepools' k8s version
 func UpgradeKubernetesVersion(cluster *management.Cluster, upgradeToVersion *string, client *rancher.Client, upgradeNodePool bool) (*management.Cluster, error) {
-	upgradedCluster := new(management.Cluster)
-	upgradedCluster.Name = cluster.Name
-	upgradedCluster.GKEConfig = cluster.GKEConfig
-	upgradedCluster.GKEConfig.KubernetesVersion = upgradeToVersion
-	if upgradeNodePool {
-		for i := range upgradedCluster.GKEConfig.NodePools {
-			upgradedCluster.GKEConfig.NodePools[i].Version = upgradeToVersion
+	return updateGKEConfig(cluster, client, func(gkeConfig *management.GKEClusterConfigSpec) error {
+		gkeConfig.KubernetesVersion = upgradeToVersion
+		if upgradeNodePool {
+			for i := range gkeConfig.NodePools {
+				gkeConfig.NodePools[i].Version = upgradeToVersion
+			}
 		}
-	}
+		return nil
+	})
+}
 
-	cluster, err := client.Management.Cluster.Update(cluster, &upgradedCluster)
-	if err != nil {
-		return nil, err
-	}
-	return cluster, nil
+// UpgradeNodePoolKubernetesVersion upgrades the k8s version of the nodepools to the value defined by upgradeToVersion, leaving the control plane as is
+func UpgradeNodePoolKubernetesVersion(cluster *management.Cluster, upgradeToVersion *string, client *rancher.Client) (*management.Cluster, error) {
+	return updateGKEConfig(cluster, client, func(gkeConfig *management.GKEClusterConfigSpec) error {
+		for i := range gkeConfig.NodePools {
+			gkeConfig.NodePools[i].Version = upgradeToVersion
+		}
+		return nil
+	})
 }
 
 // DeleteGKEHostCluster deletes the GKE cluster
@@ -41,64 +55,204 @@ func DeleteGKEHostCluster(cluster *management.Cluster, client *rancher.Client) e
 
 // AddNodePool adds a nodepool to the list
 func AddNodePool(cluster *management.Cluster, increaseBy int, client *rancher.Client) (*management.Cluster, error) {
-	upgradedCluster := new(management.Cluster)
-	upgradedCluster.Name = cluster.Name
-	upgradedCluster.GKEConfig = cluster.GKEConfig
+	return addNodePool(cluster, increaseBy, client, func(*management.GKENodePoolConfig) error { return nil })
+}
+
+// AddPreemptibleNodePool adds a nodepool of preemptible VMs to the list
+func AddPreemptibleNodePool(cluster *management.Cluster, increaseBy int, client *rancher.Client) (*management.Cluster, error) {
+	return addNodePool(cluster, increaseBy, client, func(np *management.GKENodePoolConfig) error {
+		if np.Config == nil {
+			return errors.New("no node config configured for the preemptible nodepool")
+		}
+		nodeConfig := *np.Config
+		nodeConfig.Preemptible = true
+		np.Config = &nodeConfig
+		return nil
+	})
+}
+
+// addNodePool adds increaseBy nodepools for each nodepool of the config, after applying updateFunc to them; the update is aborted if updateFunc returns an error
+func addNodePool(cluster *management.Cluster, increaseBy int, client *rancher.Client, updateFunc func(*management.GKENodePoolConfig) error) (*management.Cluster, error) {
 	nodeConfig := GkeHostNodeConfig()
 
-	for i := 1; i <= increaseBy; i++ {
-		for _, np := range nodeConfig {
-			newNodepool := management.GKENodePoolConfig{
-				InitialNodeCount:  np.InitialNodeCount,
-				Version:           np.Version,
-				Config:            np.Config,
-				Autoscaling:       np.Autoscaling,
-				Management:        np.Management,
-				MaxPodsConstraint: np.MaxPodsConstraint,
-				Name:              pointer.String(namegen.RandStringLower(5)),
+	return updateGKEConfig(cluster, client, func(gkeConfig *management.GKEClusterConfigSpec) error {
+		for i := 1; i <= increaseBy; i++ {
+			for _, np := range nodeConfig {
+				newNodepool := management.GKENodePoolConfig{
+					InitialNodeCount:  np.InitialNodeCount,
+					Version:           np.Version,
+					Config:            np.Config,
+					Autoscaling:       np.Autoscaling,
+					Management:        np.Management,
+					MaxPodsConstraint: np.MaxPodsConstraint,
+					Name:              pointer.String(namegen.RandStringLower(5)),
+				}
+				if err := updateFunc(&newNodepool); err != nil {
+					return err
+				}
+				gkeConfig.NodePools = append(gkeConfig.NodePools, newNodepool)
 			}
-			upgradedCluster.GKEConfig.NodePools = append(upgradedCluster.GKEConfig.NodePools, newNodepool)
 		}
-	}
-	cluster, err := client.Management.Cluster.Update(cluster, &upgradedCluster)
-	if err != nil {
-		return nil, err
-	}
-	return cluster, nil
+		return nil
+	})
 }
 
 // DeleteNodePool deletes a nodepool from the list
 // TODO: Modify this method to delete a custom qty of nodepool, perhaps by adding an `decreaseBy int` arg
 func DeleteNodePool(cluster *management.Cluster, client *rancher.Client) (*management.Cluster, error) {
-	upgradedCluster := new(management.Cluster)
-	upgradedCluster.Name = cluster.Name
-	upgradedCluster.GKEConfig = cluster.GKEConfig
+	return updateGKEConfig(cluster, client, func(gkeConfig *management.GKEClusterConfigSpec) error {
+		gkeConfig.NodePools = gkeConfig.NodePools[1:]
+		return nil
+	})
+}
 
-	upgradedCluster.GKEConfig.NodePools = cluster.GKEConfig.NodePools[1:]
+// ScaleNodePool modifies the number of initialNodeCount of all the nodepools as defined by nodeCount
+func ScaleNodePool(cluster *management.Cluster, client *rancher.Client, nodeCount int64) (*management.Cluster, error) {
+	return updateGKEConfig(cluster, client, func(gkeConfig *management.GKEClusterConfigSpec) error {
+		for i := range gkeConfig.NodePools {
+			gkeConfig.NodePools[i].InitialNodeCount = pointer.Int64(nodeCount)
+		}
+		return nil
+	})
+}
 
-	cluster, err := client.Management.Cluster.Update(cluster, &upgradedCluster)
-	if err != nil {
-		return nil, err
+// UpdateNodePoolLabels sets the kubernetes node labels of the nodepool named nodePoolName to the value defined by labels;
+// an empty map removes all the labels previously added to the nodepool
+func UpdateNodePoolLabels(cluster *management.Cluster, client *rancher.Client, nodePoolName string, labels map[string]string) (*management.Cluster, error) {
+	return updateNodePool(cluster, client, nodePoolName, func(np *management.GKENodePoolConfig) error {
+		if np.Config == nil {
+			np.Config = &management.GKENodeConfig{}
+		}
+		np.Config.Labels = labels
+		return nil
+	})
+}
+
+// UpdateNodePoolTaints sets the kubernetes node taints of the nodepool named nodePoolName to the value defined by taints;
+// an empty list removes all the taints previously added to the nodepool
+func UpdateNodePoolTaints(cluster *management.Cluster, client *rancher.Client, nodePoolName string, taints []management.GKENodeTaintConfig) (*management.Cluster, error) {
+	return updateNodePool(cluster, client, nodePoolName, func(np *management.GKENodePoolConfig) error {
+		if np.Config == nil {
+			np.Config = &management.GKENodeConfig{}
+		}
+		np.Config.Taints = taints
+		return nil
+	})
+}
+
+// UpdateResourceLabels sets the GCP resource labels of the cluster to the value defined by labels;
+// an empty map removes all the labels previously added to the cluster
+func UpdateResourceLabels(cluster *management.Cluster, client *rancher.Client, labels map[string]string) (*management.Cluster, error) {
+	return updateGKEConfig(cluster, client, func(gkeConfig *management.GKEClusterConfigSpec) error {
+		gkeConfig.Labels = &labels
+		return nil
+	})
+}
+
+// UpdateMasterAuthorizedNetworks enables the master authorized networks for the CIDR blocks defined by cidrBlocks, or disables them if enabled is false
+func UpdateMasterAuthorizedNetworks(cluster *management.Cluster, client *rancher.Client, enabled bool, cidrBlocks []string) (*management.Cluster, error) {
+	masterAuthorizedNetworksConfig := &management.GKEMasterAuthorizedNetworksConfig{Enabled: enabled}
+	for _, cidrBlock := range cidrBlocks {
+		masterAuthorizedNetworksConfig.CidrBlocks = append(masterAuthorizedNetworksConfig.CidrBlocks, management.GKECidrBlock{CidrBlock: cidrBlock})
 	}
-	return cluster, nil
+
+	return updateGKEConfig(cluster, client, func(gkeConfig *management.GKEClusterConfigSpec) error {
+		gkeConfig.MasterAuthorizedNetworksConfig = masterAuthorizedNetworksConfig
+		return nil
+	})
 }
 
-// ScaleNodePool modifies the number of initialNodeCount of all the nodepools as defined by nodeCount
-func ScaleNodePool(cluster *management.Cluster, client *rancher.Client, nodeCount int64) (*management.Cluster, error) {
-	upgradedCluster := new(management.Cluster)
-	upgradedCluster.Name = cluster.Name
-	upgradedCluster.GKEConfig = cluster.GKEConfig
-	for i := range upgradedCluster.GKEConfig.NodePools {
-		upgradedCluster.GKEConfig.NodePools[i].InitialNodeCount = pointer.Int64(nodeCount)
+// AdoptUpstreamSpec waits for the upstream spec of the imported cluster to be populated and adopts it into the GKE config, keeping the identity and credential of the cluster,
+// so that day-2 operations start from the nodepools that exist on GCP; it then verifies that the nodepools of the config and the upstream spec agree.
+// The GKE config of an imported cluster only holds what was set on import, i.e. no nodepools, and adding one would otherwise replace the existing ones;
+// once the operator populates the config on import, this is logged and the config is left as is.
+// On failure, the last known state of the cluster is returned along with the error, so that callers can still clean it up
+func AdoptUpstreamSpec(cluster *management.Cluster, client *rancher.Client) (*management.Cluster, error) {
+	upstreamCluster, err := helpers.WaitForUpstreamSpec(client, cluster.ID)
+	if err != nil {
+		return cluster, err
 	}
+	cluster = upstreamCluster
 
-	cluster, err := client.Management.Cluster.Update(cluster, &upgradedCluster)
+	if len(cluster.GKEConfig.NodePools) > 0 {
+		fmt.Println("GKE config of the imported cluster is already populated, the upstream spec workaround is no longer needed: ", cluster.Name)
+	} else {
+		updatedCluster, err := helpers.UpdateCluster(client, cluster.ID, func(upgradedCluster *management.Cluster) error {
+			if upgradedCluster.GKEStatus == nil || upgradedCluster.GKEStatus.UpstreamSpec == nil {
+				return errors.Errorf("upstream spec of cluster %s is not populated", upgradedCluster.Name)
+			}
+			gkeConfig := upgradedCluster.GKEStatus.UpstreamSpec
+			gkeConfig.ClusterName = upgradedCluster.GKEConfig.ClusterName
+			gkeConfig.GoogleCredentialSecret = upgradedCluster.GKEConfig.GoogleCredentialSecret
+			gkeConfig.Imported = upgradedCluster.GKEConfig.Imported
+			gkeConfig.ProjectID = upgradedCluster.GKEConfig.ProjectID
+			gkeConfig.Region = upgradedCluster.GKEConfig.Region
+			gkeConfig.Zone = upgradedCluster.GKEConfig.Zone
+			upgradedCluster.GKEConfig = gkeConfig
+			return nil
+		})
+		if err != nil {
+			return cluster, err
+		}
+		cluster = updatedCluster
+		fmt.Println("Adopted the upstream spec into the GKE config of the imported cluster: ", cluster.Name)
+	}
+
+	diff, err := helpers.DiffConfigWithUpstreamSpec(cluster, "nodePools")
 	if err != nil {
-		return nil, err
+		return cluster, err
+	}
+	if len(diff) > 0 {
+		return cluster, errors.Errorf("nodepools of the GKE config and the upstream spec of cluster %s do not agree: %v", cluster.Name, diff)
 	}
 	return cluster, nil
 }
 
+// updateGKEConfig applies updateFunc to a copy of the latest GKE config of the cluster and updates the cluster with it
+func updateGKEConfig(cluster *management.Cluster, client *rancher.Client, updateFunc func(*management.GKEClusterConfigSpec) error) (*management.Cluster, error) {
+	return helpers.UpdateCluster(client, cluster.ID, func(upgradedCluster *management.Cluster) error {
+		return updateFunc(upgradedCluster.GKEConfig)
+	})
+}
+
+// updateNodePool applies updateFunc to the nodepool named nodePoolName in the latest GKE config of the cluster and updates the cluster with it
+func updateNodePool(cluster *management.Cluster, client *rancher.Client, nodePoolName string, updateFunc func(*management.GKENodePoolConfig) error) (*management.Cluster, error) {
+	return updateGKEConfig(cluster, client, func(gkeConfig *management.GKEClusterConfigSpec) error {
+		i, err := nodePoolIndex(gkeConfig, nodePoolName)
+		if err != nil {
+			return err
+		}
+		return updateFunc(&gkeConfig.NodePools[i])
+	})
+}
+
+// ScaleNodePoolFields are the config and upstream spec fields changed by scaling, i.e. the initial node count of the nodepools
+var ScaleNodePoolFields = []string{"nodePools[*].initialNodeCount"}
+
+// NodeCountPerPool returns the number of nodes configured for each nodepool of the cluster;
+// initialNodeCount is per zone, so it is multiplied by the number of node locations of the cluster
+func NodeCountPerPool(cluster *management.Cluster) map[string]int64 {
+	zones := int64(1)
+	if cluster.GKEConfig.Locations != nil && len(*cluster.GKEConfig.Locations) > 0 {
+		zones = int64(len(*cluster.GKEConfig.Locations))
+	}
+	counts := map[string]int64{}
+	for _, np := range cluster.GKEConfig.NodePools {
+		counts[*np.Name] = *np.InitialNodeCount * zones
+	}
+	return counts
+}
+
+// nodePoolIndex returns the index of the nodepool named nodePoolName in the GKE config
+func nodePoolIndex(gkeConfig *management.GKEClusterConfigSpec, nodePoolName string) (int, error) {
+	for i, np := range gkeConfig.NodePools {
+		if np.Name != nil && *np.Name == nodePoolName {
+			return i, nil
+		}
+	}
+	return -1, errors.Errorf("nodepool %s not found in cluster %s", nodePoolName, gkeConfig.ClusterName)
+}
+
 // ListGKEAvailableVersions is a function to list and return only available GKE versions for a specific cluster.
 func ListGKEAvailableVersions(client *rancher.Client, clusterID string) (availableVersions []string, err error) {
 	// kubernetesversions.ListGKEAvailableVersions expects cluster.Version.GitVersion to be available, which it is not sometimes, so we fetch the cluster again to ensure it has all the available data
@@ -116,23 +270,35 @@ func ListSingleVariantGKEAvailableVersions(client *rancher.Client, projectID, cl
 	if err != nil {
 		return nil, err
 	}
-	var singleVersionList []string
-	var oldMinor uint64
-	for _, version := range availableVersions {
-		semVersion := semver.MustParse(version)
-		if currentMinor := semVersion.Minor(); oldMinor != currentMinor {
-			singleVersionList = append(singleVersionList, version)
-			oldMinor = currentMinor
-		}
-	}
-	return singleVersionList, nil
+	return helpers.SingleVariantVersions(availableVersions), nil
 }
 
 // Create Google GKE cluster using gcloud CLI
 func CreateGKEClusterOnGCloud(zone string, clusterName string, project string, k8sVersion string) error {
+	return CreateGKEClusterOnGCloudWithNetworking(zone, clusterName, project, k8sVersion, NetworkingConfig{Network: "default"}, nil)
+}
+
+// CreateGKEClusterOnGCloudWithNetworking creates a GKE cluster using gcloud CLI in the network and subnetwork defined by networkingConfig;
+// the nodes are private if networkingConfig.MasterIpv4CidrBlock is set, and master authorized networks are enabled for authorizedNetworks if it is not empty
+func CreateGKEClusterOnGCloudWithNetworking(zone, clusterName, project, k8sVersion string, networkingConfig NetworkingConfig, authorizedNetworks []string) error {
+	args := []string{"container", "clusters", "create", clusterName, "--project", project, "--zone", zone, "--cluster-version", k8sVersion, "--network", networkingConfig.Network, "--release-channel", "None", "--machine-type", "n2-standard-2", "--disk-size", "100", "--num-nodes", "1", "--no-enable-cloud-logging", "--no-enable-cloud-monitoring"}
+	if networkingConfig.Subnetwork != "" {
+		args = append(args, "--subnetwork", networkingConfig.Subnetwork)
+	}
+	if networkingConfig.ClusterSecondaryRangeName != "" {
+		args = append(args, "--enable-ip-alias", "--cluster-secondary-range-name", networkingConfig.ClusterSecondaryRangeName, "--services-secondary-range-name", networkingConfig.ServicesSecondaryRangeName)
+	}
+	if networkingConfig.MasterIpv4CidrBlock != "" {
+		args = append(args, "--enable-ip-alias", "--enable-private-nodes", "--master-ipv4-cidr", networkingConfig.MasterIpv4CidrBlock)
+	}
+	if len(authorizedNetworks) > 0 {
+		args = append(args, "--enable-master-authorized-networks", "--master-authorized-networks", strings.Join(authorizedNetworks, ","))
+	} else {
+		args = append(args, "--no-enable-master-authorized-networks")
+	}
 
 	fmt.Println("Creating GKE cluster ...")
-	out, err := proc.RunW("gcloud", "container", "clusters", "create", clusterName, "--project", project, "--zone", zone, "--cluster-version", k8sVersion, "--network", "default", "--release-channel", "None", "--machine-type", "n2-standard-2", "--disk-size", "100", "--num-nodes", "1", "--no-enable-cloud-logging", "--no-enable-cloud-monitoring", "--no-enable-master-authorized-networks")
+	out, err := proc.RunW("gcloud", args...)
 	if err != nil {
 		return errors.Wrap(err, "Failed to create cluster: "+out)
 	}
@@ -142,6 +308,53 @@ func CreateGKEClusterOnGCloud(zone string, clusterName string, project string, k
 	return nil
 }
 
+// GetMasterAuthorizedNetworksOnGCloud returns the master authorized networks config of the GKE cluster as reported by gcloud CLI
+func GetMasterAuthorizedNetworksOnGCloud(zone, clusterName, project string) (*management.GKEMasterAuthorizedNetworksConfig, error) {
+	out, err := proc.RunW("gcloud", "container", "clusters", "describe", clusterName, "--project", project, "--zone", zone, "--format", "json")
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to describe cluster: "+out)
+	}
+
+	var gkeCluster struct {
+		MasterAuthorizedNetworksConfig management.GKEMasterAuthorizedNetworksConfig `json:"masterAuthorizedNetworksConfig"`
+	}
+	if err = json.Unmarshal([]byte(out), &gkeCluster); err != nil {
+		return nil, errors.Wrap(err, "Failed to parse cluster description")
+	}
+	return &gkeCluster.MasterAuthorizedNetworksConfig, nil
+}
+
+// GetLabelsOnGCloud returns the resource labels of the GKE cluster using gcloud CLI
+func GetLabelsOnGCloud(zone, clusterName, project string) (map[string]string, error) {
+	out, err := proc.RunW("gcloud", "container", "clusters", "describe", clusterName, "--project", project, "--zone", zone, "--format", "json(resourceLabels)")
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to describe cluster: "+out)
+	}
+
+	var gkeCluster struct {
+		ResourceLabels map[string]string `json:"resourceLabels"`
+	}
+	if err = json.Unmarshal([]byte(out), &gkeCluster); err != nil {
+		return nil, errors.Wrap(err, "Failed to parse cluster description")
+	}
+	return gkeCluster.ResourceLabels, nil
+}
+
+// GetKubeconfigOnGCloud writes the kubeconfig of the GKE cluster to kubeconfigPath using gcloud CLI;
+// gcloud only writes credentials to the file defined by the KUBECONFIG env variable
+func GetKubeconfigOnGCloud(zone, clusterName, project, kubeconfigPath string) error {
+	cmd, err := proc.Get("", "gcloud", "container", "clusters", "get-credentials", clusterName, "--project", project, "--zone", zone)
+	if err != nil {
+		return err
+	}
+	cmd.Env = append(os.Environ(), "KUBECONFIG="+kubeconfigPath)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return errors.Wrap(err, "Failed to get cluster credentials: "+string(out))
+	}
+	return nil
+}
+
 // Complete cleanup steps for Google GKE
 func DeleteGKEClusterOnGCloud(zone string, clusterName string) error {
 
@@ -202,3 +415,16 @@ type ImportClusterConfig struct {
 	Imported  bool                            `json:"imported" yaml:"imported"`
 	NodePools []*management.GKENodePoolConfig `json:"nodePools" yaml:"nodePools"`
 }
+
+// NetworkingConfig is the configuration of the pre-existing VPC used to create private GKE clusters;
+// the subnetwork must have the secondary ranges and a Cloud NAT so that the private nodes can reach Rancher
+type NetworkingConfig struct {
+	Network                    string `json:"network" yaml:"network"`
+	Subnetwork                 string `json:"subnetwork" yaml:"subnetwork"`
+	ClusterSecondaryRangeName  string `json:"clusterSecondaryRangeName" yaml:"clusterSecondaryRangeName"`
+	ServicesSecondaryRangeName string `json:"servicesSecondaryRangeName" yaml:"servicesSecondaryRangeName"`
+	MasterIpv4CidrBlock        string `json:"masterIpv4CidrBlock" yaml:"masterIpv4CidrBlock"`
+}
+
+// NetworkingConfigConfigurationFileKey is the json/yaml config key for NetworkingConfig
+const NetworkingConfigConfigurationFileKey = "gkeNetworkingConfig"
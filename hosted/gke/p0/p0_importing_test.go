@@ -43,8 +43,8 @@ var _ = Describe("P0Importing", func() {
 			Expect(err).To(BeNil())
 			cluster, err = helpers.WaitUntilClusterIsReady(cluster, ctx.RancherClient)
 			Expect(err).To(BeNil())
-			// Workaround to add new Nodegroup till https://github.com/rancher/aks-operator/issues/251 is fixed
-			cluster.GKEConfig = cluster.GKEStatus.UpstreamSpec
+			cluster, err = helper.AdoptUpstreamSpec(cluster, ctx.RancherClient)
+			Expect(err).To(BeNil())
 		})
 		AfterEach(func() {
 			err := helper.DeleteGKEHostCluster(cluster, ctx.RancherClient)
@@ -75,6 +75,11 @@ var _ = Describe("P0Importing", func() {
 				Expect(podErrors).To(BeEmpty())
 			})
 
+			By("checking the cluster directly through its kubeconfig", func() {
+				err := helpers.VerifyClusterThroughKubeconfig(ctx.RancherClient, cluster.ID, *cluster.GKEConfig.KubernetesVersion)
+				Expect(err).To(BeNil())
+			})
+
 		})
 		Context("Upgrading K8s version", func() {
 			var upgradeToVersion *string
@@ -90,7 +95,11 @@ var _ = Describe("P0Importing", func() {
 					var err error
 					cluster, err = helper.UpgradeKubernetesVersion(cluster, upgradeToVersion, ctx.RancherClient, true)
 					Expect(err).To(BeNil())
-					err = clusters.WaitClusterToBeUpgraded(ctx.RancherClient, cluster.ID)
+					cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+					Expect(err).To(BeNil())
+					_, err = helpers.WaitForClusterGitVersion(ctx.RancherClient, cluster.ID, *upgradeToVersion)
+					Expect(err).To(BeNil())
+					err = helpers.WaitForNodePoolsKubeletVersion(ctx.RancherClient, cluster.ID, helper.NodePoolLabel, *upgradeToVersion)
 					Expect(err).To(BeNil())
 
 					Expect(cluster.GKEConfig.KubernetesVersion).To(BeEquivalentTo(upgradeToVersion))
@@ -108,7 +117,7 @@ var _ = Describe("P0Importing", func() {
 				var err error
 				cluster, err = helper.AddNodePool(cluster, increaseBy, ctx.RancherClient)
 				Expect(err).To(BeNil())
-				err = clusters.WaitClusterToBeUpgraded(ctx.RancherClient, cluster.ID)
+				cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
 				Expect(err).To(BeNil())
 				Expect(len(cluster.GKEConfig.NodePools)).To(BeNumerically("==", currentNodePoolNumber+1))
 			})
@@ -116,7 +125,7 @@ var _ = Describe("P0Importing", func() {
 				var err error
 				cluster, err = helper.DeleteNodePool(cluster, ctx.RancherClient)
 				Expect(err).To(BeNil())
-				err = clusters.WaitClusterToBeUpgraded(ctx.RancherClient, cluster.ID)
+				cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
 				Expect(err).To(BeNil())
 				Expect(len(cluster.GKEConfig.NodePools)).To(BeNumerically("==", currentNodePoolNumber))
 
@@ -128,22 +137,36 @@ var _ = Describe("P0Importing", func() {
 			initialNodeCount := *cluster.GKEConfig.NodePools[0].InitialNodeCount
 
 			By("scaling up the nodepool", func() {
-				var err error
+				snapshot, err := helpers.TakeClusterSnapshot(ctx.RancherClient, cluster.ID)
+				Expect(err).To(BeNil())
 				cluster, err = helper.ScaleNodePool(cluster, ctx.RancherClient, initialNodeCount+1)
 				Expect(err).To(BeNil())
-				err = clusters.WaitClusterToBeUpgraded(ctx.RancherClient, cluster.ID)
+				cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+				Expect(err).To(BeNil())
+				err = helpers.WaitForNodeCountPerPool(ctx.RancherClient, cluster.ID, helper.NodePoolLabel, helper.NodeCountPerPool(cluster), true)
 				Expect(err).To(BeNil())
+				diff, err := helpers.DiffClusterSince(ctx.RancherClient, cluster.ID, snapshot)
+				Expect(err).To(BeNil())
+				Expect(diff.Config.Except(helper.ScaleNodePoolFields...)).To(BeEmpty())
+				Expect(diff.UpstreamSpec.Except(helper.ScaleNodePoolFields...)).To(BeEmpty())
 				for i := range cluster.GKEConfig.NodePools {
 					Expect(*cluster.GKEConfig.NodePools[i].InitialNodeCount).To(BeNumerically("==", initialNodeCount+1))
 				}
 			})
 
 			By("scaling down the nodepool", func() {
-				var err error
+				snapshot, err := helpers.TakeClusterSnapshot(ctx.RancherClient, cluster.ID)
+				Expect(err).To(BeNil())
 				cluster, err = helper.ScaleNodePool(cluster, ctx.RancherClient, initialNodeCount)
 				Expect(err).To(BeNil())
-				err = clusters.WaitClusterToBeUpgraded(ctx.RancherClient, cluster.ID)
+				cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+				Expect(err).To(BeNil())
+				err = helpers.WaitForNodeCountPerPool(ctx.RancherClient, cluster.ID, helper.NodePoolLabel, helper.NodeCountPerPool(cluster), true)
+				Expect(err).To(BeNil())
+				diff, err := helpers.DiffClusterSince(ctx.RancherClient, cluster.ID, snapshot)
 				Expect(err).To(BeNil())
+				Expect(diff.Config.Except(helper.ScaleNodePoolFields...)).To(BeEmpty())
+				Expect(diff.UpstreamSpec.Except(helper.ScaleNodePoolFields...)).To(BeEmpty())
 				for i := range cluster.GKEConfig.NodePools {
 					Expect(*cluster.GKEConfig.NodePools[i].InitialNodeCount).To(BeNumerically("==", initialNodeCount))
 				}
@@ -9,6 +9,8 @@ import (
 	nodestat "github.com/rancher/rancher/tests/framework/extensions/nodes"
 	"github.com/rancher/rancher/tests/framework/extensions/workloads/pods"
 	namegen "github.com/rancher/rancher/tests/framework/pkg/namegenerator"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
 
 	"github.com/valaparthvi/highlander-tests/hosted/gke/helper"
 	"github.com/valaparthvi/highlander-tests/hosted/helpers"
@@ -32,13 +34,14 @@ var _ = Describe("P0Provisioning", func() {
 			var err error
 			cluster, err = gke.CreateGKEHostedCluster(ctx.RancherClient, clusterName, ctx.CloudCred.ID, false, false, false, false, map[string]string{})
 			Expect(err).To(BeNil())
+			// deleted by a cleanup so that it outlives the volume cleanups of the specs
+			DeferCleanup(func() {
+				err := helper.DeleteGKEHostCluster(cluster, ctx.RancherClient)
+				Expect(err).To(BeNil())
+			})
 			cluster, err = helpers.WaitUntilClusterIsReady(cluster, ctx.RancherClient)
 			Expect(err).To(BeNil())
 		})
-		AfterEach(func() {
-			err := helper.DeleteGKEHostCluster(cluster, ctx.RancherClient)
-			Expect(err).To(BeNil())
-		})
 		It("should successfully provision the cluster", func() {
 
 			By("checking cluster name is same", func() {
@@ -61,6 +64,16 @@ var _ = Describe("P0Provisioning", func() {
 				Expect(podErrors).To(BeEmpty())
 			})
 
+			By("checking the cluster directly through its kubeconfig", func() {
+				err := helpers.VerifyClusterThroughKubeconfig(ctx.RancherClient, cluster.ID, *cluster.GKEConfig.KubernetesVersion)
+				Expect(err).To(BeNil())
+			})
+
+			By("checking a LoadBalancer service and an ingress are reachable", func() {
+				err := helpers.VerifyLoadBalancerServiceAndIngress(ctx.RancherClient, cluster.ID)
+				Expect(err).To(BeNil())
+			})
+
 		})
 		Context("Upgrading K8s version", func() {
 			var upgradeToVersion, currentVersion *string
@@ -73,11 +86,18 @@ var _ = Describe("P0Provisioning", func() {
 			})
 
 			It("should be able to upgrade k8s version of the cluster", func() {
+				var (
+					clientset  *kubernetes.Clientset
+					volumeData *helpers.VolumeData
+				)
+
 				By("upgrading the ControlPlane", func() {
 					var err error
 					cluster, err = helper.UpgradeKubernetesVersion(cluster, upgradeToVersion, ctx.RancherClient, false)
 					Expect(err).To(BeNil())
-					err = clusters.WaitClusterToBeUpgraded(ctx.RancherClient, cluster.ID)
+					cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+					Expect(err).To(BeNil())
+					_, err = helpers.WaitForClusterGitVersion(ctx.RancherClient, cluster.ID, *upgradeToVersion)
 					Expect(err).To(BeNil())
 					Expect(cluster.GKEConfig.KubernetesVersion).To(BeEquivalentTo(upgradeToVersion))
 					for _, np := range cluster.GKEConfig.NodePools {
@@ -85,11 +105,22 @@ var _ = Describe("P0Provisioning", func() {
 					}
 				})
 
+				By("writing data to a persistent volume", func() {
+					var err error
+					clientset, err = helpers.GetDownstreamClientset(ctx.RancherClient, cluster.ID)
+					Expect(err).To(BeNil())
+					volumeData, err = helpers.WriteVolumeData(clientset)
+					Expect(err).To(BeNil())
+					DeferCleanup(helpers.DeleteVolumeData, clientset, volumeData)
+				})
+
 				By("upgrading the NodePools", func() {
 					var err error
 					cluster, err = helper.UpgradeKubernetesVersion(cluster, upgradeToVersion, ctx.RancherClient, true)
 					Expect(err).To(BeNil())
-					err = clusters.WaitClusterToBeUpgraded(ctx.RancherClient, cluster.ID)
+					cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+					Expect(err).To(BeNil())
+					err = helpers.WaitForNodePoolsKubeletVersion(ctx.RancherClient, cluster.ID, helper.NodePoolLabel, *upgradeToVersion)
 					Expect(err).To(BeNil())
 
 					Expect(cluster.GKEConfig.KubernetesVersion).To(BeEquivalentTo(upgradeToVersion))
@@ -97,6 +128,18 @@ var _ = Describe("P0Provisioning", func() {
 						Expect(np.Version).To(BeEquivalentTo(upgradeToVersion))
 					}
 				})
+
+				By("verifying the persistent volume data from a rescheduled pod", func() {
+					reader, err := helpers.VerifyVolumeData(clientset, volumeData)
+					Expect(err).To(BeNil())
+					// the upgrade replaces every node of the nodepools, so the volume must have moved to a new node
+					Expect(reader).ToNot(Equal(volumeData.Writer))
+				})
+
+				By("checking a LoadBalancer service and an ingress are reachable", func() {
+					err := helpers.VerifyLoadBalancerServiceAndIngress(ctx.RancherClient, cluster.ID)
+					Expect(err).To(BeNil())
+				})
 			})
 		})
 
@@ -107,7 +150,7 @@ var _ = Describe("P0Provisioning", func() {
 				var err error
 				cluster, err = helper.AddNodePool(cluster, increaseBy, ctx.RancherClient)
 				Expect(err).To(BeNil())
-				err = clusters.WaitClusterToBeUpgraded(ctx.RancherClient, cluster.ID)
+				cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
 				Expect(err).To(BeNil())
 				Expect(len(cluster.GKEConfig.NodePools)).To(BeNumerically("==", currentNodePoolNumber+1))
 			})
@@ -115,7 +158,7 @@ var _ = Describe("P0Provisioning", func() {
 				var err error
 				cluster, err = helper.DeleteNodePool(cluster, ctx.RancherClient)
 				Expect(err).To(BeNil())
-				err = clusters.WaitClusterToBeUpgraded(ctx.RancherClient, cluster.ID)
+				cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
 				Expect(err).To(BeNil())
 				Expect(len(cluster.GKEConfig.NodePools)).To(BeNumerically("==", currentNodePoolNumber))
 
@@ -123,30 +166,220 @@ var _ = Describe("P0Provisioning", func() {
 
 		})
 
+		It("should be possible to add a preemptible nodepool", func() {
+			var nodePoolName string
+
+			By("adding a preemptible nodepool", func() {
+				var err error
+				cluster, err = helper.AddPreemptibleNodePool(cluster, increaseBy, ctx.RancherClient)
+				Expect(err).To(BeNil())
+				cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+				Expect(err).To(BeNil())
+				nodePoolName = *cluster.GKEConfig.NodePools[len(cluster.GKEConfig.NodePools)-1].Name
+			})
+
+			By("checking the nodepool is reported as preemptible by the upstream spec", func() {
+				Expect(cluster.GKEStatus.UpstreamSpec.NodePools).To(ContainElement(And(
+					HaveField("Name", Equal(&nodePoolName)),
+					HaveField("Config.Preemptible", BeTrue()),
+				)))
+			})
+
+			By("checking the nodes have the preemptible label", func() {
+				nodes, err := helpers.ListDownstreamNodes(ctx.RancherClient, cluster.ID, helper.NodePoolLabel+"="+nodePoolName)
+				Expect(err).To(BeNil())
+				Expect(nodes).ToNot(BeEmpty())
+				for _, node := range nodes {
+					Expect(node.Labels).To(HaveKeyWithValue(helper.PreemptibleLabel, "true"))
+				}
+			})
+		})
+
+		It("should be possible to add, update and remove the nodepool labels and taints", func() {
+			var nodePoolName string
+			taint := corev1.Taint{Key: "highlander", Value: "true", Effect: corev1.TaintEffectNoSchedule}
+
+			By("adding a nodepool", func() {
+				var err error
+				cluster, err = helper.AddNodePool(cluster, increaseBy, ctx.RancherClient)
+				Expect(err).To(BeNil())
+				cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+				Expect(err).To(BeNil())
+				nodePoolName = *cluster.GKEConfig.NodePools[len(cluster.GKEConfig.NodePools)-1].Name
+			})
+
+			By("adding labels and taints to the nodepool", func() {
+				var err error
+				cluster, err = helper.UpdateNodePoolLabels(cluster, ctx.RancherClient, nodePoolName, map[string]string{"highlander": "add"})
+				Expect(err).To(BeNil())
+				cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+				Expect(err).To(BeNil())
+				cluster, err = helper.UpdateNodePoolTaints(cluster, ctx.RancherClient, nodePoolName, []management.GKENodeTaintConfig{{Key: "highlander", Value: "true", Effect: "NO_SCHEDULE"}})
+				Expect(err).To(BeNil())
+				cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+				Expect(err).To(BeNil())
+
+				nodes, err := helpers.ListDownstreamNodes(ctx.RancherClient, cluster.ID, helper.NodePoolLabel+"="+nodePoolName)
+				Expect(err).To(BeNil())
+				Expect(nodes).ToNot(BeEmpty())
+				for _, node := range nodes {
+					Expect(node.Labels).To(HaveKeyWithValue("highlander", "add"))
+					Expect(node.Spec.Taints).To(ContainElement(taint))
+				}
+			})
+
+			By("updating the nodepool labels", func() {
+				var err error
+				cluster, err = helper.UpdateNodePoolLabels(cluster, ctx.RancherClient, nodePoolName, map[string]string{"highlander": "update"})
+				Expect(err).To(BeNil())
+				cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+				Expect(err).To(BeNil())
+
+				nodes, err := helpers.ListDownstreamNodes(ctx.RancherClient, cluster.ID, helper.NodePoolLabel+"="+nodePoolName)
+				Expect(err).To(BeNil())
+				Expect(nodes).ToNot(BeEmpty())
+				for _, node := range nodes {
+					Expect(node.Labels).To(HaveKeyWithValue("highlander", "update"))
+				}
+			})
+
+			By("removing the nodepool labels and taints", func() {
+				var err error
+				cluster, err = helper.UpdateNodePoolLabels(cluster, ctx.RancherClient, nodePoolName, map[string]string{})
+				Expect(err).To(BeNil())
+				cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+				Expect(err).To(BeNil())
+				cluster, err = helper.UpdateNodePoolTaints(cluster, ctx.RancherClient, nodePoolName, []management.GKENodeTaintConfig{})
+				Expect(err).To(BeNil())
+				cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+				Expect(err).To(BeNil())
+
+				nodes, err := helpers.ListDownstreamNodes(ctx.RancherClient, cluster.ID, helper.NodePoolLabel+"="+nodePoolName)
+				Expect(err).To(BeNil())
+				Expect(nodes).ToNot(BeEmpty())
+				for _, node := range nodes {
+					Expect(node.Labels).ToNot(HaveKey("highlander"))
+					Expect(node.Spec.Taints).ToNot(ContainElement(taint))
+				}
+			})
+		})
+
+		It("should be possible to add, update and remove the cluster labels and resource labels", func() {
+			By("adding cluster labels and resource labels", func() {
+				var err error
+				cluster, err = helpers.UpdateClusterLabels(cluster, ctx.RancherClient, map[string]string{"highlander": "add"}, nil)
+				Expect(err).To(BeNil())
+				cluster, err = helper.UpdateResourceLabels(cluster, ctx.RancherClient, map[string]string{"highlander": "add"})
+				Expect(err).To(BeNil())
+				cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+				Expect(err).To(BeNil())
+				Expect(cluster.Labels).To(HaveKeyWithValue("highlander", "add"))
+
+				resourceLabels, err := helper.GetLabelsOnGCloud(cluster.GKEConfig.Zone, clusterName, cluster.GKEConfig.ProjectID)
+				Expect(err).To(BeNil())
+				Expect(resourceLabels).To(HaveKeyWithValue("highlander", "add"))
+			})
+
+			By("updating cluster labels and resource labels", func() {
+				var err error
+				cluster, err = helpers.UpdateClusterLabels(cluster, ctx.RancherClient, map[string]string{"highlander": "update"}, nil)
+				Expect(err).To(BeNil())
+				cluster, err = helper.UpdateResourceLabels(cluster, ctx.RancherClient, map[string]string{"highlander": "update"})
+				Expect(err).To(BeNil())
+				cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+				Expect(err).To(BeNil())
+				Expect(cluster.Labels).To(HaveKeyWithValue("highlander", "update"))
+
+				resourceLabels, err := helper.GetLabelsOnGCloud(cluster.GKEConfig.Zone, clusterName, cluster.GKEConfig.ProjectID)
+				Expect(err).To(BeNil())
+				Expect(resourceLabels).To(HaveKeyWithValue("highlander", "update"))
+			})
+
+			By("removing cluster labels and resource labels", func() {
+				var err error
+				cluster, err = helpers.UpdateClusterLabels(cluster, ctx.RancherClient, nil, []string{"highlander"})
+				Expect(err).To(BeNil())
+				cluster, err = helper.UpdateResourceLabels(cluster, ctx.RancherClient, map[string]string{})
+				Expect(err).To(BeNil())
+				cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+				Expect(err).To(BeNil())
+				Expect(cluster.Labels).ToNot(HaveKey("highlander"))
+
+				resourceLabels, err := helper.GetLabelsOnGCloud(cluster.GKEConfig.Zone, clusterName, cluster.GKEConfig.ProjectID)
+				Expect(err).To(BeNil())
+				Expect(resourceLabels).ToNot(HaveKey("highlander"))
+			})
+		})
+
+		It("should be possible to install, upgrade and uninstall a catalog app", func() {
+			helpers.VerifyCatalogAppLifecycle(ctx.RancherClient, cluster.ID)
+		})
+
 		It("should be possible to scale up/down the nodepool", func() {
+			var (
+				clientset  *kubernetes.Clientset
+				volumeData *helpers.VolumeData
+			)
+
 			initialNodeCount := *cluster.GKEConfig.NodePools[0].InitialNodeCount
 
 			By("scaling up the nodepool", func() {
-				var err error
+				snapshot, err := helpers.TakeClusterSnapshot(ctx.RancherClient, cluster.ID)
+				Expect(err).To(BeNil())
 				cluster, err = helper.ScaleNodePool(cluster, ctx.RancherClient, initialNodeCount+1)
 				Expect(err).To(BeNil())
-				err = clusters.WaitClusterToBeUpgraded(ctx.RancherClient, cluster.ID)
+				cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+				Expect(err).To(BeNil())
+				err = helpers.WaitForNodeCountPerPool(ctx.RancherClient, cluster.ID, helper.NodePoolLabel, helper.NodeCountPerPool(cluster), true)
 				Expect(err).To(BeNil())
+				diff, err := helpers.DiffClusterSince(ctx.RancherClient, cluster.ID, snapshot)
+				Expect(err).To(BeNil())
+				Expect(diff.Config.Except(helper.ScaleNodePoolFields...)).To(BeEmpty())
+				Expect(diff.UpstreamSpec.Except(helper.ScaleNodePoolFields...)).To(BeEmpty())
 				for i := range cluster.GKEConfig.NodePools {
 					Expect(*cluster.GKEConfig.NodePools[i].InitialNodeCount).To(BeNumerically("==", initialNodeCount+1))
 				}
 			})
 
-			By("scaling down the nodepool", func() {
+			By("writing data to a persistent volume", func() {
 				var err error
+				clientset, err = helpers.GetDownstreamClientset(ctx.RancherClient, cluster.ID)
+				Expect(err).To(BeNil())
+				volumeData, err = helpers.WriteVolumeData(clientset)
+				Expect(err).To(BeNil())
+				DeferCleanup(helpers.DeleteVolumeData, clientset, volumeData)
+			})
+
+			By("scaling down the nodepool", func() {
+				snapshot, err := helpers.TakeClusterSnapshot(ctx.RancherClient, cluster.ID)
+				Expect(err).To(BeNil())
 				cluster, err = helper.ScaleNodePool(cluster, ctx.RancherClient, initialNodeCount)
 				Expect(err).To(BeNil())
-				err = clusters.WaitClusterToBeUpgraded(ctx.RancherClient, cluster.ID)
+				cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
 				Expect(err).To(BeNil())
+				err = helpers.WaitForNodeCountPerPool(ctx.RancherClient, cluster.ID, helper.NodePoolLabel, helper.NodeCountPerPool(cluster), true)
+				Expect(err).To(BeNil())
+				diff, err := helpers.DiffClusterSince(ctx.RancherClient, cluster.ID, snapshot)
+				Expect(err).To(BeNil())
+				Expect(diff.Config.Except(helper.ScaleNodePoolFields...)).To(BeEmpty())
+				Expect(diff.UpstreamSpec.Except(helper.ScaleNodePoolFields...)).To(BeEmpty())
 				for i := range cluster.GKEConfig.NodePools {
 					Expect(*cluster.GKEConfig.NodePools[i].InitialNodeCount).To(BeNumerically("==", initialNodeCount))
 				}
 			})
+
+			By("verifying the persistent volume data from a rescheduled pod", func() {
+				reader, err := helpers.VerifyVolumeData(clientset, volumeData)
+				Expect(err).To(BeNil())
+				// the nodepool picks the node to remove, so the volume only has to move if its node was the one removed
+				writerGone, err := helpers.IsVolumeNodeGone(clientset, volumeData.Writer)
+				Expect(err).To(BeNil())
+				if writerGone {
+					Expect(reader).ToNot(Equal(volumeData.Writer))
+				} else {
+					AddReportEntry("persistent volume not rescheduled", "the scale down kept node "+volumeData.Writer.Name+", which wrote the data")
+				}
+			})
 		})
 	})
 })
@@ -0,0 +1,92 @@
+package p1_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	management "github.com/rancher/rancher/tests/framework/clients/rancher/generated/management/v3"
+	"github.com/rancher/rancher/tests/framework/extensions/clusters/gke"
+	"github.com/rancher/rancher/tests/framework/pkg/config"
+	namegen "github.com/rancher/rancher/tests/framework/pkg/namegenerator"
+	"k8s.io/utils/pointer"
+
+	"github.com/valaparthvi/highlander-tests/hosted/gke/helper"
+	"github.com/valaparthvi/highlander-tests/hosted/helpers"
+)
+
+var _ = Describe("P1ClusterFeatures", func() {
+	var (
+		clusterName string
+		ctx         helpers.Context
+		cluster     *management.Cluster
+	)
+	var _ = BeforeEach(func() {
+		clusterName = namegen.AppendRandomString("gkehostcluster")
+		ctx = helpers.CommonBeforeSuite("gke")
+		cluster = nil
+	})
+	AfterEach(func() {
+		if cluster == nil {
+			return
+		}
+		err := helper.DeleteGKEHostCluster(cluster, ctx.RancherClient)
+		Expect(err).To(BeNil())
+	})
+
+	DescribeTable("a cluster is created with a cluster feature enabled",
+		func(enableClusterAlerting, enableClusterMonitoring, enableNetworkPolicy bool) {
+			if enableClusterAlerting || enableClusterMonitoring {
+				legacyEnabled, err := helpers.IsFeatureEnabled(ctx.RancherClient, helpers.LegacyFeature)
+				Expect(err).To(BeNil())
+				if !legacyEnabled {
+					Skip("monitoring v1 and alerting v1 require the " + helpers.LegacyFeature + " feature flag, which is disabled")
+				}
+			}
+
+			helpers.PreserveConfig()
+			gkeConfig := new(gke.ClusterConfig)
+			config.LoadAndUpdateConfig(gke.GKEClusterConfigConfigurationFileKey, gkeConfig, func() {
+				if enableNetworkPolicy {
+					// Rancher rejects enableNetworkPolicy unless the cluster has network policy enforcement enabled
+					gkeConfig.NetworkPolicyEnabled = pointer.Bool(true)
+					if gkeConfig.ClusterAddons == nil {
+						gkeConfig.ClusterAddons = new(gke.ClusterAddons)
+					}
+					gkeConfig.ClusterAddons.NetworkPolicyConfig = true
+				}
+			})
+			var err error
+			cluster, err = gke.CreateGKEHostedCluster(ctx.RancherClient, clusterName, ctx.CloudCred.ID, enableClusterAlerting, enableClusterMonitoring, enableNetworkPolicy, false, map[string]string{})
+			Expect(err).To(BeNil())
+			cluster, err = helpers.WaitUntilClusterIsReady(cluster, ctx.RancherClient)
+			Expect(err).To(BeNil())
+
+			if enableClusterMonitoring {
+				By("checking the monitoring components are deployed", func() {
+					err := helpers.WaitForClusterCondition(ctx.RancherClient, cluster.ID, helpers.MonitoringEnabledCondition)
+					Expect(err).To(BeNil())
+					clientset, err := helpers.GetDownstreamClientset(ctx.RancherClient, cluster.ID)
+					Expect(err).To(BeNil())
+					err = helpers.WaitForNamespacePodsToBeReady(clientset, helpers.MonitoringV1Namespace)
+					Expect(err).To(BeNil())
+				})
+			}
+
+			if enableClusterAlerting {
+				By("checking the alerting components are deployed", func() {
+					err := helpers.WaitForClusterCondition(ctx.RancherClient, cluster.ID, helpers.AlertingEnabledCondition)
+					Expect(err).To(BeNil())
+				})
+			}
+
+			if enableNetworkPolicy {
+				By("checking traffic between projects is denied", func() {
+					err := helpers.VerifyProjectNetworkIsolation(ctx.RancherClient, cluster.ID)
+					Expect(err).To(BeNil())
+				})
+			}
+		},
+		Entry("cluster monitoring", false, true, false),
+		Entry("cluster alerting", true, false, false),
+		Entry("project network isolation", false, false, true),
+	)
+})
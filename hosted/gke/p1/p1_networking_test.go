@@ -0,0 +1,192 @@
+package p1_test
+
+import (
+	"os"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	management "github.com/rancher/rancher/tests/framework/clients/rancher/generated/management/v3"
+	"github.com/rancher/rancher/tests/framework/extensions/clusters"
+	"github.com/rancher/rancher/tests/framework/extensions/clusters/gke"
+	nodestat "github.com/rancher/rancher/tests/framework/extensions/nodes"
+	"github.com/rancher/rancher/tests/framework/extensions/workloads/pods"
+	"github.com/rancher/rancher/tests/framework/pkg/config"
+	namegen "github.com/rancher/rancher/tests/framework/pkg/namegenerator"
+	"k8s.io/utils/pointer"
+
+	"github.com/valaparthvi/highlander-tests/hosted/gke/helper"
+	"github.com/valaparthvi/highlander-tests/hosted/helpers"
+)
+
+var _ = Describe("P1Networking", func() {
+	var (
+		clusterName        string
+		ctx                helpers.Context
+		cluster            *management.Cluster
+		networkingConfig   *helper.NetworkingConfig
+		authorizedNetworks []string
+	)
+	var _ = BeforeEach(func() {
+		clusterName = namegen.AppendRandomString("gkehostcluster")
+		ctx = helpers.CommonBeforeSuite("gke")
+		cluster = nil
+
+		networkingConfig = new(helper.NetworkingConfig)
+		config.LoadConfig(helper.NetworkingConfigConfigurationFileKey, networkingConfig)
+		if networkingConfig.Subnetwork == "" {
+			Skip("subnetwork is not set in the " + helper.NetworkingConfigConfigurationFileKey + " config")
+		}
+		// PUBLIC_IP is the public IP of the Rancher server, as set by the CI workflow; gke-operator needs it to reach the cluster API
+		publicIP := os.Getenv("PUBLIC_IP")
+		if publicIP == "" {
+			Skip("PUBLIC_IP is not set; master authorized networks cannot be restricted to the Rancher server")
+		}
+		authorizedNetworks = []string{publicIP + "/32"}
+		helpers.PreserveConfig()
+	})
+
+	// checkClusterIsReachable checks that Rancher reaches the private nodes through the cattle cluster agent
+	checkClusterIsReachable := func() {
+		By("checking the cattle cluster agent is connected", func() {
+			Expect(helpers.IsClusterConnected(cluster)).To(BeTrue())
+			success, err := clusters.CheckServiceAccountTokenSecret(ctx.RancherClient, clusterName)
+			Expect(err).To(BeNil())
+			Expect(success).To(BeTrue())
+		})
+
+		By("checking all management nodes are ready", func() {
+			err := nodestat.AllManagementNodeReady(ctx.RancherClient, cluster.ID, helpers.Timeout)
+			Expect(err).To(BeNil())
+		})
+
+		By("checking all pods are ready", func() {
+			podErrors := pods.StatusPods(ctx.RancherClient, cluster.ID)
+			Expect(podErrors).To(BeEmpty())
+		})
+	}
+
+	// checkNetworkingIsReported checks the networking settings are reported by the upstream spec
+	checkNetworkingIsReported := func() {
+		By("checking the networking settings are reported by the upstream spec", func() {
+			upstreamSpec := cluster.GKEStatus.UpstreamSpec
+			Expect(*upstreamSpec.Network).To(HaveSuffix(networkingConfig.Network))
+			Expect(*upstreamSpec.Subnetwork).To(HaveSuffix(networkingConfig.Subnetwork))
+			Expect(upstreamSpec.IPAllocationPolicy.ClusterSecondaryRangeName).To(Equal(networkingConfig.ClusterSecondaryRangeName))
+			Expect(upstreamSpec.IPAllocationPolicy.ServicesSecondaryRangeName).To(Equal(networkingConfig.ServicesSecondaryRangeName))
+			Expect(upstreamSpec.PrivateClusterConfig.EnablePrivateNodes).To(BeTrue())
+			Expect(upstreamSpec.PrivateClusterConfig.MasterIpv4CidrBlock).To(Equal(networkingConfig.MasterIpv4CidrBlock))
+			Expect(upstreamSpec.MasterAuthorizedNetworksConfig.Enabled).To(BeTrue())
+			Expect(upstreamSpec.MasterAuthorizedNetworksConfig.CidrBlocks).To(ContainElement(HaveField("CidrBlock", authorizedNetworks[0])))
+		})
+	}
+
+	// checkAuthorizedNetworksCanBeToggled disables the master authorized networks, and enables them again with an additional network
+	checkAuthorizedNetworksCanBeToggled := func() {
+		zone, project := cluster.GKEConfig.Zone, cluster.GKEConfig.ProjectID
+
+		By("disabling the master authorized networks", func() {
+			var err error
+			cluster, err = helper.UpdateMasterAuthorizedNetworks(cluster, ctx.RancherClient, false, nil)
+			Expect(err).To(BeNil())
+			cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+			Expect(err).To(BeNil())
+
+			masterAuthorizedNetworksConfig, err := helper.GetMasterAuthorizedNetworksOnGCloud(zone, clusterName, project)
+			Expect(err).To(BeNil())
+			Expect(masterAuthorizedNetworksConfig.Enabled).To(BeFalse())
+		})
+
+		By("enabling the master authorized networks with an additional network", func() {
+			// 203.0.113.0/24 is reserved for documentation, so it never authorizes an actual client
+			cidrBlocks := append([]string{"203.0.113.0/24"}, authorizedNetworks...)
+			var err error
+			cluster, err = helper.UpdateMasterAuthorizedNetworks(cluster, ctx.RancherClient, true, cidrBlocks)
+			Expect(err).To(BeNil())
+			cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+			Expect(err).To(BeNil())
+
+			masterAuthorizedNetworksConfig, err := helper.GetMasterAuthorizedNetworksOnGCloud(zone, clusterName, project)
+			Expect(err).To(BeNil())
+			Expect(masterAuthorizedNetworksConfig.Enabled).To(BeTrue())
+			var actualCidrBlocks []string
+			for _, cidrBlock := range masterAuthorizedNetworksConfig.CidrBlocks {
+				actualCidrBlocks = append(actualCidrBlocks, cidrBlock.CidrBlock)
+			}
+			Expect(actualCidrBlocks).To(ConsistOf(cidrBlocks))
+		})
+	}
+
+	When("a private cluster is created with master authorized networks", func() {
+		BeforeEach(func() {
+			var err error
+			gkeConfig := new(gke.ClusterConfig)
+			config.LoadAndUpdateConfig(gke.GKEClusterConfigConfigurationFileKey, gkeConfig, func() {
+				gkeConfig.Network = pointer.String(networkingConfig.Network)
+				gkeConfig.Subnetwork = pointer.String(networkingConfig.Subnetwork)
+				gkeConfig.IPAllocationPolicy = &gke.IPAllocationPolicy{
+					UseIPAliases:               true,
+					ClusterSecondaryRangeName:  networkingConfig.ClusterSecondaryRangeName,
+					ServicesSecondaryRangeName: networkingConfig.ServicesSecondaryRangeName,
+				}
+				gkeConfig.PrivateClusterConfig = &gke.PrivateClusterConfig{
+					EnablePrivateNodes:  true,
+					MasterIpv4CidrBlock: networkingConfig.MasterIpv4CidrBlock,
+				}
+				gkeConfig.MasterAuthorizedNetworksConfig = &gke.MasterAuthorizedNetworksConfig{
+					Enabled:    true,
+					CidrBlocks: []gke.CidrBlock{{CidrBlock: authorizedNetworks[0], DisplayName: "rancher"}},
+				}
+			})
+			cluster, err = gke.CreateGKEHostedCluster(ctx.RancherClient, clusterName, ctx.CloudCred.ID, false, false, false, false, map[string]string{})
+			Expect(err).To(BeNil())
+			cluster, err = helpers.WaitUntilClusterIsReady(cluster, ctx.RancherClient)
+			Expect(err).To(BeNil())
+		})
+		AfterEach(func() {
+			err := helper.DeleteGKEHostCluster(cluster, ctx.RancherClient)
+			Expect(err).To(BeNil())
+		})
+
+		It("should successfully provision the cluster and update its master authorized networks", func() {
+			checkNetworkingIsReported()
+			checkClusterIsReachable()
+			checkAuthorizedNetworksCanBeToggled()
+		})
+	})
+
+	When("a private cluster with master authorized networks is imported", func() {
+		var zone, project string
+
+		BeforeEach(func() {
+			var err error
+			gkeClusterConfig := new(gke.ClusterConfig)
+			config.LoadConfig(gke.GKEClusterConfigConfigurationFileKey, gkeClusterConfig)
+			gkeConfig := new(helper.ImportClusterConfig)
+			config.LoadAndUpdateConfig(gke.GKEClusterConfigConfigurationFileKey, gkeConfig, func() {
+				gkeConfig.Imported = true
+			})
+			zone, project = gkeConfig.Zone, gkeConfig.ProjectID
+
+			err = helper.CreateGKEClusterOnGCloudWithNetworking(zone, clusterName, project, *gkeClusterConfig.KubernetesVersion, *networkingConfig, authorizedNetworks)
+			Expect(err).To(BeNil())
+			cluster, err = helper.ImportGKEHostedCluster(ctx.RancherClient, clusterName, ctx.CloudCred.ID, false, false, false, false, map[string]string{})
+			Expect(err).To(BeNil())
+			cluster, err = helpers.WaitUntilClusterIsReady(cluster, ctx.RancherClient)
+			Expect(err).To(BeNil())
+			cluster, err = helper.AdoptUpstreamSpec(cluster, ctx.RancherClient)
+			Expect(err).To(BeNil())
+		})
+		AfterEach(func() {
+			err := helper.DeleteGKEHostCluster(cluster, ctx.RancherClient)
+			Expect(err).To(BeNil())
+			err = helper.DeleteGKEClusterOnGCloud(zone, clusterName)
+			Expect(err).To(BeNil())
+		})
+
+		It("should successfully import the cluster and update its master authorized networks", func() {
+			checkNetworkingIsReported()
+			checkClusterIsReachable()
+			checkAuthorizedNetworksCanBeToggled()
+		})
+	})
+})
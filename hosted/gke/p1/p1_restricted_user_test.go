@@ -0,0 +1,100 @@
+package p1_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rancher/rancher/tests/framework/clients/rancher"
+	management "github.com/rancher/rancher/tests/framework/clients/rancher/generated/management/v3"
+	"github.com/rancher/rancher/tests/framework/extensions/cloudcredentials"
+	"github.com/rancher/rancher/tests/framework/extensions/clusters/gke"
+	namegen "github.com/rancher/rancher/tests/framework/pkg/namegenerator"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/valaparthvi/highlander-tests/hosted/gke/helper"
+	"github.com/valaparthvi/highlander-tests/hosted/helpers"
+)
+
+var _ = Describe("P1RestrictedUser", func() {
+	var (
+		clusterName string
+		ctx         helpers.Context
+		cluster     *management.Cluster
+		user        *management.User
+		userClient  *rancher.Client
+		userCred    *cloudcredentials.CloudCredential
+	)
+	var _ = BeforeEach(func() {
+		clusterName = namegen.AppendRandomString("gkehostcluster")
+		ctx = helpers.CommonBeforeSuite("gke")
+		cluster = nil
+
+		var err error
+		user, userClient, err = helpers.CreateUser(ctx.RancherClient, helpers.UserBaseGlobalRole, helpers.ClustersCreateGlobalRole)
+		Expect(err).To(BeNil())
+		userCred, err = helpers.CreateCloudCredential(userClient, "gke")
+		Expect(err).To(BeNil())
+	})
+	AfterEach(func() {
+		if cluster == nil {
+			return
+		}
+		err := helper.DeleteGKEHostCluster(cluster, ctx.RancherClient)
+		Expect(err).To(BeNil())
+	})
+
+	It("should let a user with only the cluster creation permission provision and manage a cluster", func() {
+		By("creating the cluster with the user's cloud credential", func() {
+			var err error
+			cluster, err = gke.CreateGKEHostedCluster(userClient, clusterName, userCred.ID, false, false, false, false, map[string]string{})
+			Expect(err).To(BeNil())
+			cluster, err = helpers.WaitUntilClusterIsReady(cluster, ctx.RancherClient)
+			Expect(err).To(BeNil())
+			// log in again, so that the client picks up the permissions granted by the ownership of the cluster
+			userClient, err = userClient.ReLogin()
+			Expect(err).To(BeNil())
+		})
+
+		By("checking the user is the owner of the cluster", func() {
+			isOwner, err := helpers.IsClusterOwner(ctx.RancherClient, cluster.ID, user)
+			Expect(err).To(BeNil())
+			Expect(isOwner).To(BeTrue())
+		})
+
+		By("checking the user can only see their own cloud credential", func() {
+			canSee, err := helpers.CanSeeCloudCredential(userClient, userCred.ID)
+			Expect(err).To(BeNil())
+			Expect(canSee).To(BeTrue())
+			canSee, err = helpers.CanSeeCloudCredential(userClient, ctx.CloudCred.ID)
+			Expect(err).To(BeNil())
+			Expect(canSee).To(BeFalse())
+		})
+
+		By("checking the user can list the pods of every namespace", func() {
+			allowed, err := helpers.CanListPods(userClient, cluster.ID, metav1.NamespaceSystem)
+			Expect(err).To(BeNil())
+			Expect(allowed).To(BeTrue())
+		})
+
+		By("scaling up the nodepool as the user", func() {
+			initialNodeCount := *cluster.GKEConfig.NodePools[0].InitialNodeCount
+			var err error
+			cluster, err = helper.ScaleNodePool(cluster, userClient, initialNodeCount+1)
+			Expect(err).To(BeNil())
+			cluster, err = helpers.WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+			Expect(err).To(BeNil())
+			err = helpers.WaitForNodeCountPerPool(ctx.RancherClient, cluster.ID, helper.NodePoolLabel, helper.NodeCountPerPool(cluster), true)
+			Expect(err).To(BeNil())
+			for i := range cluster.GKEConfig.NodePools {
+				Expect(*cluster.GKEConfig.NodePools[i].InitialNodeCount).To(BeNumerically("==", initialNodeCount+1))
+			}
+		})
+
+		By("deleting the cluster as the user", func() {
+			err := helper.DeleteGKEHostCluster(cluster, userClient)
+			Expect(err).To(BeNil())
+			err = helpers.WaitUntilClusterIsDeleted(ctx.RancherClient, cluster.ID)
+			Expect(err).To(BeNil())
+			cluster = nil
+		})
+	})
+})
@@ -0,0 +1,41 @@
+package p1_test
+
+import (
+	"github.com/rancher/rancher/tests/framework/clients/rancher"
+	management "github.com/rancher/rancher/tests/framework/clients/rancher/generated/management/v3"
+	"github.com/rancher/rancher/tests/framework/extensions/clusters/gke"
+	"github.com/rancher/rancher/tests/framework/pkg/config"
+	namegen "github.com/rancher/rancher/tests/framework/pkg/namegenerator"
+
+	"github.com/valaparthvi/highlander-tests/hosted/gke/helper"
+	"github.com/valaparthvi/highlander-tests/hosted/helpers"
+)
+
+var _ = helpers.DescribeVersionSkew(helpers.VersionSkewProvider{
+	Cloud: "gke",
+	ListVersions: func(ctx helpers.Context) ([]string, error) {
+		gkeConfig := new(gke.ClusterConfig)
+		config.LoadConfig(gke.GKEClusterConfigConfigurationFileKey, gkeConfig)
+		return helper.ListSingleVariantGKEAvailableVersions(ctx.RancherClient, gkeConfig.ProjectID, ctx.CloudCred.ID, gkeConfig.Zone, gkeConfig.Region)
+	},
+	CreateCluster: func(ctx helpers.Context, k8sVersion string) (*management.Cluster, error) {
+		clusterName := namegen.AppendRandomString("gkehostcluster")
+		helpers.PreserveConfig()
+		gkeConfig := new(gke.ClusterConfig)
+		config.LoadAndUpdateConfig(gke.GKEClusterConfigConfigurationFileKey, gkeConfig, func() {
+			gkeConfig.KubernetesVersion = &k8sVersion
+		})
+		return gke.CreateGKEHostedCluster(ctx.RancherClient, clusterName, ctx.CloudCred.ID, false, false, false, false, map[string]string{})
+	},
+	DeleteCluster: helper.DeleteGKEHostCluster,
+	UpgradeControlPlane: func(cluster *management.Cluster, upgradeToVersion *string, client *rancher.Client) (*management.Cluster, error) {
+		return helper.UpgradeKubernetesVersion(cluster, upgradeToVersion, client, false)
+	},
+	UpgradeNodes: helper.UpgradeNodePoolKubernetesVersion,
+	// GKE reports for e.g. "Node version \"1.28.3-gke.1203000\" must not be newer than master version \"1.27.7-gke.1121000\""
+	NewerNodesRejection: `(?i)node version \S+ must not be newer than master version`,
+	// for e.g. "Master version \"1.28.3-gke.1203000\" must be within 2 minor versions of node version \"1.25.15-gke.1000\""
+	NodeSkewRejection: `(?i)master version \S+ must be within \d+ minor versions? of node`,
+	// for e.g. "Master cannot be upgraded to \"1.28.3-gke.1203000\": cannot skip minor versions"
+	SkippedMinorRejection: `(?i)master cannot be upgraded to \S+.*skip.*minor version`,
+})
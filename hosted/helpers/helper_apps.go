@@ -0,0 +1,247 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	"github.com/rancher/rancher/pkg/api/steve/catalog/types"
+	catalogv1 "github.com/rancher/rancher/pkg/apis/catalog.cattle.io/v1"
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/rancher/tests/framework/clients/rancher"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kwait "k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// CatalogAppChartName is the rancher-charts chart installed by the catalog app specs; it is small and does not depend on a CRD chart
+	CatalogAppChartName = "rancher-alerting-drivers"
+	// CatalogAppNamespace is the namespace CatalogAppChartName is installed in
+	CatalogAppNamespace = "cattle-monitoring-system"
+	// CatalogAppUpgradeDeployment is the deployment added by upgrading CatalogAppChartName with CatalogAppUpgradeValues
+	CatalogAppUpgradeDeployment = "rancher-alerting-drivers-sachet"
+
+	catalogAppTimeout = 10 * time.Minute
+)
+
+// CatalogAppUpgradeValues are the values the catalog app specs upgrade CatalogAppChartName with; they enable the sachet driver, which is disabled by default
+var CatalogAppUpgradeValues = map[string]interface{}{
+	"sachet": map[string]interface{}{"enabled": true},
+}
+
+// ListCatalogChartVersions returns the versions of chartName in the rancher-charts catalog of the cluster, from the newest to the oldest
+func ListCatalogChartVersions(client *rancher.Client, clusterID, chartName string) ([]string, error) {
+	catalogClient, err := client.GetClusterCatalogClient(clusterID)
+	if err != nil {
+		return nil, err
+	}
+	return catalogClient.GetListChartVersions(chartName)
+}
+
+// InstallCatalogApp installs version of chartName from the rancher-charts catalog as an app of the same name in namespace,
+// the way the Rancher UI does, and waits for it to be deployed; values are merged over the global values set by Rancher
+func InstallCatalogApp(client *rancher.Client, clusterID, chartName, namespace, version string, values map[string]interface{}) (*catalogv1.App, error) {
+	catalogClient, err := client.GetClusterCatalogClient(clusterID)
+	if err != nil {
+		return nil, err
+	}
+	chartValues, err := catalogAppValues(client, clusterID, values)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Println("Installing app", chartName, version, "...")
+	err = catalogClient.InstallChart(&types.ChartInstallAction{
+		Timeout:   &metav1.Duration{Duration: catalogAppTimeout},
+		Wait:      true,
+		Namespace: namespace,
+		Charts: []types.ChartInstall{{
+			ChartName:   chartName,
+			ReleaseName: chartName,
+			Version:     version,
+			Values:      chartValues,
+			Annotations: catalogAppAnnotations(),
+		}},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to install app "+chartName)
+	}
+	return WaitForCatalogAppToBeDeployed(client, clusterID, chartName, namespace, version, 1)
+}
+
+// VerifyCatalogAppLifecycle installs the previous version of CatalogAppChartName in the cluster, upgrades it to the latest one
+// with CatalogAppUpgradeValues and uninstalls it; the spec is skipped if the catalog has a single version of the chart.
+// If the spec fails before the uninstallation, the app is uninstalled by a cleanup
+func VerifyCatalogAppLifecycle(client *rancher.Client, clusterID string) {
+	versions, err := ListCatalogChartVersions(client, clusterID, CatalogAppChartName)
+	Expect(err).To(BeNil())
+	if len(versions) < 2 {
+		ginkgo.Skip("the upgrade requires two versions of " + CatalogAppChartName + ", found: " + strings.Join(versions, ", "))
+	}
+
+	uninstalled := false
+	ginkgo.By("installing the previous version of the app", func() {
+		app, err := InstallCatalogApp(client, clusterID, CatalogAppChartName, CatalogAppNamespace, versions[1], nil)
+		ginkgo.DeferCleanup(func() {
+			if uninstalled {
+				return
+			}
+			catalogClient, err := client.GetClusterCatalogClient(clusterID)
+			Expect(err).To(BeNil())
+			_, err = catalogClient.Apps(CatalogAppNamespace).Get(context.TODO(), CatalogAppChartName, metav1.GetOptions{})
+			if apierrors.IsNotFound(err) {
+				return
+			}
+			err = UninstallCatalogApp(client, clusterID, CatalogAppChartName, CatalogAppNamespace)
+			Expect(err).To(BeNil())
+		})
+		Expect(err).To(BeNil())
+		Expect(app.Spec.Chart.Metadata.Version).To(Equal(versions[1]))
+	})
+
+	ginkgo.By("upgrading the app to the latest version", func() {
+		app, err := UpgradeCatalogApp(client, clusterID, CatalogAppChartName, CatalogAppNamespace, versions[0], CatalogAppUpgradeValues)
+		Expect(err).To(BeNil())
+		Expect(app.Spec.Version).To(Equal(2))
+		Expect(app.Spec.Chart.Metadata.Version).To(Equal(versions[0]))
+		_, err = WaitForDeploymentToBeAvailable(client, clusterID, CatalogAppNamespace, CatalogAppUpgradeDeployment)
+		Expect(err).To(BeNil())
+	})
+
+	ginkgo.By("uninstalling the app", func() {
+		err := UninstallCatalogApp(client, clusterID, CatalogAppChartName, CatalogAppNamespace)
+		Expect(err).To(BeNil())
+		uninstalled = true
+	})
+}
+
+// UpgradeCatalogApp upgrades the app installed by InstallCatalogApp to version with values, and waits for the new revision to be deployed
+func UpgradeCatalogApp(client *rancher.Client, clusterID, chartName, namespace, version string, values map[string]interface{}) (*catalogv1.App, error) {
+	catalogClient, err := client.GetClusterCatalogClient(clusterID)
+	if err != nil {
+		return nil, err
+	}
+	app, err := catalogClient.Apps(namespace).Get(context.TODO(), chartName, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	chartValues, err := catalogAppValues(client, clusterID, values)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Println("Upgrading app", chartName, "to", version, "...")
+	err = catalogClient.UpgradeChart(&types.ChartUpgradeAction{
+		Timeout:   &metav1.Duration{Duration: catalogAppTimeout},
+		Wait:      true,
+		Namespace: namespace,
+		Charts: []types.ChartUpgrade{{
+			ChartName:   chartName,
+			ReleaseName: chartName,
+			Version:     version,
+			Values:      chartValues,
+			Annotations: catalogAppAnnotations(),
+		}},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to upgrade app "+chartName)
+	}
+	return WaitForCatalogAppToBeDeployed(client, clusterID, chartName, namespace, version, app.Spec.Version+1)
+}
+
+// UninstallCatalogApp uninstalls the app and waits for it to be removed
+func UninstallCatalogApp(client *rancher.Client, clusterID, chartName, namespace string) error {
+	catalogClient, err := client.GetClusterCatalogClient(clusterID)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Uninstalling app", chartName, "...")
+	err = catalogClient.UninstallChart(chartName, namespace, &types.ChartUninstallAction{})
+	if err != nil {
+		return errors.Wrap(err, "Failed to uninstall app "+chartName)
+	}
+	err = kwait.Poll(5*time.Second, catalogAppTimeout, func() (bool, error) {
+		_, err := catalogClient.Apps(namespace).Get(context.TODO(), chartName, metav1.GetOptions{})
+		return apierrors.IsNotFound(err), nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "app "+chartName+" was not removed")
+	}
+	return nil
+}
+
+// WaitForCatalogAppToBeDeployed waits until revision of the app is deployed with version of the chart, and returns the app;
+// the revision is the Helm release revision, i.e. 1 once installed and incremented by every upgrade
+func WaitForCatalogAppToBeDeployed(client *rancher.Client, clusterID, chartName, namespace, version string, revision int) (*catalogv1.App, error) {
+	catalogClient, err := client.GetClusterCatalogClient(clusterID)
+	if err != nil {
+		return nil, err
+	}
+	var app *catalogv1.App
+	err = kwait.Poll(5*time.Second, catalogAppTimeout, func() (bool, error) {
+		app, err = catalogClient.Apps(namespace).Get(context.TODO(), chartName, metav1.GetOptions{})
+		if err != nil {
+			return false, nil
+		}
+		if app.Spec.Version < revision || app.Spec.Chart == nil || app.Spec.Chart.Metadata == nil {
+			return false, nil
+		}
+		if app.Status.Summary.Error {
+			return false, errors.Errorf("app %s is in %s state", chartName, app.Status.Summary.State)
+		}
+		return app.Status.Summary.State == string(catalogv1.StatusDeployed) && app.Spec.Chart.Metadata.Version == version, nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "app %s %s revision %d was not deployed", chartName, version, revision)
+	}
+	fmt.Println("App", chartName, version, "is deployed, revision", app.Spec.Version)
+	return app, nil
+}
+
+// catalogAppValues returns values along with the global values the Rancher UI sets for every app it installs
+func catalogAppValues(client *rancher.Client, clusterID string, values map[string]interface{}) (v3.MapStringInterface, error) {
+	cluster, err := client.Management.Cluster.ByID(clusterID)
+	if err != nil {
+		return nil, err
+	}
+	serverURL, err := client.Management.Setting.ByID("server-url")
+	if err != nil {
+		return nil, err
+	}
+	defaultRegistry, err := client.Management.Setting.ByID("system-default-registry")
+	if err != nil {
+		return nil, err
+	}
+
+	chartValues := v3.MapStringInterface{
+		"global": map[string]interface{}{
+			"cattle": map[string]string{
+				"clusterId":             clusterID,
+				"clusterName":           cluster.Name,
+				"rkePathPrefix":         "",
+				"rkeWindowsPathPrefix":  "",
+				"systemDefaultRegistry": defaultRegistry.Value,
+				"url":                   serverURL.Value,
+			},
+			"systemDefaultRegistry": defaultRegistry.Value,
+		},
+	}
+	for k, v := range values {
+		chartValues[k] = v
+	}
+	return chartValues, nil
+}
+
+// catalogAppAnnotations returns the annotations the Rancher UI sets on apps installed from the rancher-charts catalog
+func catalogAppAnnotations() map[string]string {
+	return map[string]string{
+		"catalog.cattle.io/ui-source-repo":      "rancher-charts",
+		"catalog.cattle.io/ui-source-repo-type": "cluster",
+	}
+}
@@ -1,7 +1,13 @@
 package helpers
 
 import (
+	"fmt"
+	"os"
+
+	"github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	"github.com/rancher/norman/types"
 	"github.com/rancher/rancher/tests/framework/clients/rancher"
 	management "github.com/rancher/rancher/tests/framework/clients/rancher/generated/management/v3"
 	"github.com/rancher/rancher/tests/framework/extensions/cloudcredentials"
@@ -13,6 +19,7 @@ import (
 	"github.com/rancher/rancher/tests/framework/pkg/wait"
 	"github.com/rancher/rancher/tests/v2prov/defaults"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kwait "k8s.io/apimachinery/pkg/util/wait"
 	"time"
 )
 
@@ -26,47 +33,161 @@ type Context struct {
 	Session       *session.Session
 }
 
+// CommonBeforeSuite creates a session, a client and a cloud credential for the cloud;
+// the resources created through the session, such as users and role bindings, are removed once the spec or suite is done
 func CommonBeforeSuite(cloud string) Context {
 	testSession := session.NewSession()
-	var cloudCredential *cloudcredentials.CloudCredential
+	ginkgo.DeferCleanup(testSession.Cleanup)
 
 	rancherClient, err := rancher.NewClient("", testSession)
 	Expect(err).To(BeNil())
 
+	cloudCredential, err := CreateCloudCredential(rancherClient, cloud)
+	Expect(err).To(BeNil())
+
+	return Context{
+		CloudCred:     cloudCredential,
+		RancherClient: rancherClient,
+		Session:       testSession,
+	}
+}
+
+// CreateCloudCredential creates a cloud credential for the cloud (aks, eks or gke) from the cloud credential config, owned by the user of the client;
+// the credential is deleted when the client's session is cleaned up, once the clusters using it are removed
+func CreateCloudCredential(client *rancher.Client, cloud string) (*cloudcredentials.CloudCredential, error) {
+	var cloudCredential *cloudcredentials.CloudCredential
+	var err error
 	switch cloud {
 	case "aks":
-		cloudCredential, err = azure.CreateAzureCloudCredentials(rancherClient)
-		Expect(err).To(BeNil())
+		cloudCredential, err = azure.CreateAzureCloudCredentials(client)
 	case "eks":
-		cloudCredential, err = aws.CreateAWSCloudCredentials(rancherClient)
-		Expect(err).To(BeNil())
+		cloudCredential, err = aws.CreateAWSCloudCredentials(client)
 	case "gke":
-		cloudCredential, err = google.CreateGoogleCloudCredentials(rancherClient)
-		Expect(err).To(BeNil())
+		cloudCredential, err = google.CreateGoogleCloudCredentials(client)
+	default:
+		return nil, errors.New("unsupported cloud " + cloud)
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	return Context{
-		CloudCred:     cloudCredential,
-		RancherClient: rancherClient,
-		Session:       testSession,
+	// the operators need the credential to remove the cloud resources of a hosted cluster, and the cleanup functions of a session
+	// run in reverse order, so this wait runs before the deletion of the credential registered by its creation
+	client.Session.RegisterCleanupFunc(func() error {
+		err := waitForCloudCredentialToBeUnused(client, cloudCredential.ID)
+		if err != nil {
+			fmt.Println(err)
+		}
+		return nil
+	})
+	return cloudCredential, nil
+}
+
+// waitForCloudCredentialToBeUnused waits until no hosted cluster visible to the client uses the cloud credential
+func waitForCloudCredentialToBeUnused(client *rancher.Client, cloudCredentialID string) error {
+	var clusterNames []string
+	err := kwait.Poll(10*time.Second, Timeout, func() (bool, error) {
+		clusterList, err := client.Management.Cluster.List(&types.ListOpts{})
+		if err != nil {
+			return false, nil
+		}
+		clusterNames = nil
+		for _, cluster := range clusterList.Data {
+			if usesCloudCredential(&cluster, cloudCredentialID) {
+				clusterNames = append(clusterNames, cluster.Name)
+			}
+		}
+		return len(clusterNames) == 0, nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "cloud credential %s is still used by clusters %v", cloudCredentialID, clusterNames)
+	}
+	return nil
+}
+
+// usesCloudCredential returns true if the hosted cluster is configured with the cloud credential
+func usesCloudCredential(cluster *management.Cluster, cloudCredentialID string) bool {
+	switch {
+	case cluster.AKSConfig != nil:
+		return cluster.AKSConfig.AzureCredentialSecret == cloudCredentialID
+	case cluster.EKSConfig != nil:
+		return cluster.EKSConfig.AmazonCredentialSecret == cloudCredentialID
+	case cluster.GKEConfig != nil:
+		return cluster.GKEConfig.GoogleCredentialSecret == cloudCredentialID
 	}
+	return false
 }
 
 // WaitUntilClusterIsReady waits until the cluster is in a Ready state,
 // fetch the cluster again once it's ready so that it has everything up to date and then return it.
-// For e.g. once the cluster has been updated, it contains information such as Version.GitVersion which it does not have before it's ready
+// For e.g. once the cluster has been updated, it contains information such as Version.GitVersion which it does not have before it's ready.
+// On failure, the given cluster is returned along with the error, so that callers can still clean it up
 func WaitUntilClusterIsReady(cluster *management.Cluster, client *rancher.Client) (*management.Cluster, error) {
 	opts := metav1.ListOptions{FieldSelector: "metadata.name=" + cluster.ID, TimeoutSeconds: &defaults.WatchTimeoutSeconds}
 	watchInterface, err := client.GetManagementWatchInterface(management.ClusterType, opts)
 	if err != nil {
-		return nil, err
+		return cluster, err
 	}
 
 	watchFunc := clusters.IsHostedProvisioningClusterReady
 
 	err = wait.WatchWait(watchInterface, watchFunc)
 	if err != nil {
-		return nil, err
+		return cluster, err
 	}
 	return client.Management.Cluster.ByID(cluster.ID)
 }
+
+// PreserveConfig backs up the CATTLE_TEST_CONFIG file and restores it once the current spec is done,
+// so that the config.LoadAndUpdateConfig changes made for a spec variant do not leak into other specs
+func PreserveConfig() {
+	configPath := os.Getenv("CATTLE_TEST_CONFIG")
+	if configPath == "" {
+		return
+	}
+	content, err := os.ReadFile(configPath)
+	Expect(err).To(BeNil())
+	ginkgo.DeferCleanup(func() {
+		err := os.WriteFile(configPath, content, 0644)
+		Expect(err).To(BeNil())
+	})
+}
+
+// IsClusterConnected checks if the cattle cluster agent of the cluster is connected to Rancher
+func IsClusterConnected(cluster *management.Cluster) bool {
+	for _, cond := range cluster.Conditions {
+		if cond.Type == "Connected" && cond.Status == "True" {
+			return true
+		}
+	}
+	return false
+}
+
+// GetClusterRegistrationToken returns the registration token of the cluster, which holds the command to deploy the cattle cluster agent
+func GetClusterRegistrationToken(client *rancher.Client, clusterID string) (*management.ClusterRegistrationToken, error) {
+	tokens, err := client.Management.ClusterRegistrationToken.List(&types.ListOpts{Filters: map[string]interface{}{"clusterId": clusterID}})
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens.Data) == 0 {
+		return nil, errors.Errorf("no registration token found for cluster %s", clusterID)
+	}
+	return &tokens.Data[0], nil
+}
+
+// UpdateClusterLabels adds or updates the Rancher labels of the cluster to the values defined by labels, and removes the labels whose keys are in removeLabels;
+// the labels added by Rancher itself are preserved
+func UpdateClusterLabels(cluster *management.Cluster, client *rancher.Client, labels map[string]string, removeLabels []string) (*management.Cluster, error) {
+	return UpdateCluster(client, cluster.ID, func(upgradedCluster *management.Cluster) error {
+		if upgradedCluster.Labels == nil {
+			upgradedCluster.Labels = map[string]string{}
+		}
+		for key, value := range labels {
+			upgradedCluster.Labels[key] = value
+		}
+		for _, key := range removeLabels {
+			delete(upgradedCluster.Labels, key)
+		}
+		return nil
+	})
+}
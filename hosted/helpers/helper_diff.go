@@ -0,0 +1,222 @@
+package helpers
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/rancher/rancher/tests/framework/clients/rancher"
+	management "github.com/rancher/rancher/tests/framework/clients/rancher/generated/management/v3"
+)
+
+// poolKeys are the fields that identify the elements of a list in the flattened field paths, i.e. the nodepool and nodegroup names
+var poolKeys = []string{"name", "nodegroupName"}
+
+// ClusterSnapshot is a flattened copy of the provider config and the upstream spec of a cluster, keyed by field path;
+// elements of the nodepool and nodegroup lists are identified by their name, e.g. `nodePools[name=abcde].count`
+type ClusterSnapshot struct {
+	Config       map[string]interface{}
+	UpstreamSpec map[string]interface{}
+}
+
+// FieldChange is a field whose value differs between two snapshots; a nil value means that the field is not set
+type FieldChange struct {
+	Path   string
+	Before interface{}
+	After  interface{}
+}
+
+func (c FieldChange) String() string {
+	return fmt.Sprintf("%s: %v -> %v", c.Path, c.Before, c.After)
+}
+
+// FieldChanges is a list of field changes sorted by path
+type FieldChanges []FieldChange
+
+// Except returns the changes whose path does not match any of allowedPaths;
+// a path matches if it is equal to or nested under an allowed path, and `*` matches any list element, e.g. `nodePools[*].count`
+func (c FieldChanges) Except(allowedPaths ...string) FieldChanges {
+	return c.filter(allowedPaths, false)
+}
+
+// Only returns the changes whose path matches any of paths, using the same matching as Except
+func (c FieldChanges) Only(paths ...string) FieldChanges {
+	return c.filter(paths, true)
+}
+
+// filter returns the changes whose path matching any of paths is equal to match
+func (c FieldChanges) filter(paths []string, match bool) FieldChanges {
+	var patterns []*regexp.Regexp
+	for _, path := range paths {
+		pattern := strings.ReplaceAll(regexp.QuoteMeta(path), `\*`, `[^\]]*`)
+		patterns = append(patterns, regexp.MustCompile(`^`+pattern+`($|[.\[])`))
+	}
+
+	var filtered FieldChanges
+	for _, change := range c {
+		matched := false
+		for _, pattern := range patterns {
+			if pattern.MatchString(change.Path) {
+				matched = true
+				break
+			}
+		}
+		if matched == match {
+			filtered = append(filtered, change)
+		}
+	}
+	return filtered
+}
+
+// ClusterDiff is the difference between two snapshots of a cluster
+type ClusterDiff struct {
+	Config       FieldChanges
+	UpstreamSpec FieldChanges
+}
+
+// TakeClusterSnapshot fetches the cluster and snapshots its provider config and upstream spec
+func TakeClusterSnapshot(client *rancher.Client, clusterID string) (*ClusterSnapshot, error) {
+	cluster, err := client.Management.Cluster.ByID(clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	config, upstreamSpec := providerSpecs(cluster)
+	snapshot := &ClusterSnapshot{Config: map[string]interface{}{}, UpstreamSpec: map[string]interface{}{}}
+	if err = flattenSpec(config, snapshot.Config); err != nil {
+		return nil, errors.Wrap(err, "failed to snapshot config of cluster "+cluster.Name)
+	}
+	if err = flattenSpec(upstreamSpec, snapshot.UpstreamSpec); err != nil {
+		return nil, errors.Wrap(err, "failed to snapshot upstream spec of cluster "+cluster.Name)
+	}
+	return snapshot, nil
+}
+
+// DiffClusterSnapshots returns the fields that differ between the before and after snapshots
+func DiffClusterSnapshots(before, after *ClusterSnapshot) ClusterDiff {
+	return ClusterDiff{
+		Config:       diffFields(before.Config, after.Config),
+		UpstreamSpec: diffFields(before.UpstreamSpec, after.UpstreamSpec),
+	}
+}
+
+// DiffClusterSince snapshots the cluster again and returns the fields that differ from the before snapshot
+func DiffClusterSince(client *rancher.Client, clusterID string, before *ClusterSnapshot) (ClusterDiff, error) {
+	after, err := TakeClusterSnapshot(client, clusterID)
+	if err != nil {
+		return ClusterDiff{}, err
+	}
+	return DiffClusterSnapshots(before, after), nil
+}
+
+// DiffConfigWithUpstreamSpec returns the fields that differ between the provider config and the upstream spec of the cluster,
+// restricted to the fields matching paths; the config is taken as the before value
+func DiffConfigWithUpstreamSpec(cluster *management.Cluster, paths ...string) (FieldChanges, error) {
+	config, upstreamSpec := providerSpecs(cluster)
+	configFields, upstreamFields := map[string]interface{}{}, map[string]interface{}{}
+	if err := flattenSpec(config, configFields); err != nil {
+		return nil, errors.Wrap(err, "failed to flatten config of cluster "+cluster.Name)
+	}
+	if err := flattenSpec(upstreamSpec, upstreamFields); err != nil {
+		return nil, errors.Wrap(err, "failed to flatten upstream spec of cluster "+cluster.Name)
+	}
+	return diffFields(configFields, upstreamFields).Only(paths...), nil
+}
+
+// providerSpecs returns the provider config and upstream spec of the cluster, whichever provider it belongs to
+func providerSpecs(cluster *management.Cluster) (config, upstreamSpec interface{}) {
+	switch {
+	case cluster.AKSConfig != nil:
+		if cluster.AKSStatus != nil && cluster.AKSStatus.UpstreamSpec != nil {
+			upstreamSpec = cluster.AKSStatus.UpstreamSpec
+		}
+		return cluster.AKSConfig, upstreamSpec
+	case cluster.EKSConfig != nil:
+		if cluster.EKSStatus != nil && cluster.EKSStatus.UpstreamSpec != nil {
+			upstreamSpec = cluster.EKSStatus.UpstreamSpec
+		}
+		return cluster.EKSConfig, upstreamSpec
+	case cluster.GKEConfig != nil:
+		if cluster.GKEStatus != nil && cluster.GKEStatus.UpstreamSpec != nil {
+			upstreamSpec = cluster.GKEStatus.UpstreamSpec
+		}
+		return cluster.GKEConfig, upstreamSpec
+	}
+	return nil, nil
+}
+
+// flattenSpec converts spec to its JSON representation and adds each of its leaf values to fields
+func flattenSpec(spec interface{}, fields map[string]interface{}) error {
+	if spec == nil {
+		return nil
+	}
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return err
+	}
+	var value interface{}
+	if err = json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	flattenValue("", value, fields)
+	return nil
+}
+
+// flattenValue adds the leaf values of value to fields, keyed by their path under prefix; unset values are not added
+func flattenValue(prefix string, value interface{}, fields map[string]interface{}) {
+	switch v := value.(type) {
+	case nil:
+	case map[string]interface{}:
+		for key, item := range v {
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+			flattenValue(key, item, fields)
+		}
+	case []interface{}:
+		for i, item := range v {
+			flattenValue(prefix+"["+elementKey(item, i)+"]", item, fields)
+		}
+	default:
+		fields[prefix] = v
+	}
+}
+
+// elementKey returns the name of a nodepool or nodegroup list element, or its index for any other list
+func elementKey(item interface{}, index int) string {
+	if m, ok := item.(map[string]interface{}); ok {
+		for _, key := range poolKeys {
+			if name, ok := m[key].(string); ok && name != "" {
+				return key + "=" + name
+			}
+		}
+	}
+	return strconv.Itoa(index)
+}
+
+// diffFields returns the changes between the before and after fields, sorted by path
+func diffFields(before, after map[string]interface{}) FieldChanges {
+	paths := map[string]bool{}
+	for path := range before {
+		paths[path] = true
+	}
+	for path := range after {
+		paths[path] = true
+	}
+
+	var changes FieldChanges
+	for path := range paths {
+		if !reflect.DeepEqual(before[path], after[path]) {
+			changes = append(changes, FieldChange{Path: path, Before: before[path], After: after[path]})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes
+}
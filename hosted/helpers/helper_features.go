@@ -0,0 +1,173 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rancher/rancher/tests/framework/clients/rancher"
+	kubeservices "github.com/rancher/rancher/tests/framework/extensions/kubeapi/services"
+	namegen "github.com/rancher/rancher/tests/framework/pkg/namegenerator"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	kwait "k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// LegacyFeature is the Rancher feature flag required by monitoring v1 and alerting v1, i.e. the enableClusterMonitoring and
+	// enableClusterAlerting cluster fields
+	LegacyFeature = "legacy"
+	// MonitoringV1Namespace is the namespace of the monitoring v1 and alerting v1 components
+	MonitoringV1Namespace = "cattle-prometheus"
+	// MonitoringEnabledCondition is the cluster condition set once monitoring v1 is deployed
+	MonitoringEnabledCondition = "MonitoringEnabled"
+	// AlertingEnabledCondition is the cluster condition set once alerting v1 is deployed
+	AlertingEnabledCondition = "AlertingEnabled"
+	// DefaultNetworkPolicyName is the name of the NetworkPolicy Rancher creates in every namespace of a project when project network isolation is enabled
+	DefaultNetworkPolicyName = "np-default"
+)
+
+// IsFeatureEnabled returns true if the Rancher feature flag is enabled, either explicitly or by default
+func IsFeatureEnabled(client *rancher.Client, featureName string) (bool, error) {
+	feature, err := client.Management.Feature.ByID(featureName)
+	if err != nil {
+		return false, err
+	}
+	if feature.Value != nil {
+		return *feature.Value, nil
+	}
+	return feature.Status != nil && feature.Status.Default, nil
+}
+
+// WaitForClusterCondition waits until the condition of the cluster is True
+func WaitForClusterCondition(client *rancher.Client, clusterID, conditionType string) error {
+	err := kwait.Poll(10*time.Second, Timeout, func() (bool, error) {
+		cluster, err := client.Management.Cluster.ByID(clusterID)
+		if err != nil {
+			return false, nil
+		}
+		for _, cond := range cluster.Conditions {
+			if cond.Type == conditionType && cond.Status == "True" {
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "condition %s of cluster %s is not True", conditionType, clusterID)
+	}
+	return nil
+}
+
+// WaitForNamespacePodsToBeReady waits until the namespace has pods and all of them are running and ready, or have completed
+func WaitForNamespacePodsToBeReady(clientset *kubernetes.Clientset, namespace string) error {
+	err := kwait.Poll(10*time.Second, Timeout, func() (bool, error) {
+		pods, err := clientset.CoreV1().Pods(namespace).List(context.TODO(), metav1.ListOptions{})
+		if err != nil || len(pods.Items) == 0 {
+			return false, nil
+		}
+		for _, pod := range pods.Items {
+			if pod.Status.Phase == corev1.PodSucceeded {
+				continue
+			}
+			if pod.Status.Phase != corev1.PodRunning {
+				return false, nil
+			}
+			for _, containerStatus := range pod.Status.ContainerStatuses {
+				if !containerStatus.Ready {
+					return false, nil
+				}
+			}
+		}
+		return true, nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "pods of namespace "+namespace+" are not ready")
+	}
+	return nil
+}
+
+// VerifyProjectNetworkIsolation checks the cluster enforces Rancher's project network isolation: it serves nginx from a namespace of
+// one project, and checks it is reachable from the same namespace but not from a namespace of another project
+func VerifyProjectNetworkIsolation(client *rancher.Client, clusterID string) error {
+	clientset, err := GetDownstreamClientset(client, clusterID)
+	if err != nil {
+		return err
+	}
+
+	_, serverNamespace, err := CreateProjectAndNamespace(client, clusterID, namegen.AppendRandomString("highlander-server"))
+	if err != nil {
+		return err
+	}
+	_, clientNamespace, err := CreateProjectAndNamespace(client, clusterID, namegen.AppendRandomString("highlander-client"))
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Waiting for Rancher to create the network policy of namespace", serverNamespace.Name, "...")
+	err = kwait.Poll(5*time.Second, 5*time.Minute, func() (bool, error) {
+		_, err := clientset.NetworkingV1().NetworkPolicies(serverNamespace.Name).Get(context.TODO(), DefaultNetworkPolicyName, metav1.GetOptions{})
+		return err == nil, nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "no network policy created in namespace "+serverNamespace.Name)
+	}
+
+	name := "highlander-server"
+	deployment, err := CreateDeploymentOnNodes(client, clusterID, serverNamespace.Name, name, loadBalancerImage, map[string]string{corev1.LabelOSStable: "linux"})
+	if err != nil {
+		return errors.Wrap(err, "Failed to create deployment "+name)
+	}
+	_, err = WaitForDeploymentToBeAvailable(client, clusterID, serverNamespace.Name, name)
+	if err != nil {
+		return errors.Wrap(err, "deployment "+name+" is not available")
+	}
+	service, err := kubeservices.CreateService(client, clusterID, name, serverNamespace.Name, corev1.ServiceSpec{
+		Selector: deployment.Spec.Selector.MatchLabels,
+		Ports:    []corev1.ServicePort{{Name: "http", Port: 80, TargetPort: intstr.FromInt(80), Protocol: corev1.ProtocolTCP}},
+	})
+	if err != nil {
+		return errors.Wrap(err, "Failed to create service "+name)
+	}
+	// the service is reached through its cluster IP, so that a DNS failure cannot be mistaken for the traffic being denied
+	url := fmt.Sprintf("http://%s", service.Spec.ClusterIP)
+
+	fmt.Println("Checking", url, "is reachable from namespace", serverNamespace.Name, "...")
+	// retry for a while, so that a failure is not due to the service endpoints not being programmed yet
+	script := fmt.Sprintf("for i in $(seq 1 12); do wget -q -T 5 -O /dev/null %s && exit 0; sleep 5; done; exit 1", url)
+	_, err = RunPodToCompletion(clientset, networkCheckPod(serverNamespace.Name, script))
+	if err != nil {
+		return errors.Wrap(err, url+" is not reachable from its own project")
+	}
+
+	fmt.Println("Checking", url, "is not reachable from namespace", clientNamespace.Name, "...")
+	// the network policy drops the traffic, so the request must time out rather than fail for any other reason, such as the connection being refused
+	script = fmt.Sprintf(`out=$(wget -q -T 10 -O /dev/null %s 2>&1); status=$?; echo "$out"; [ $status -ne 0 ] && echo "$out" | grep -q "timed out"`, url)
+	_, err = RunPodToCompletion(clientset, networkCheckPod(clientNamespace.Name, script))
+	if err != nil {
+		return errors.Wrap(err, url+" is reachable from another project")
+	}
+	return nil
+}
+
+// networkCheckPod returns a pod running script in namespace
+func networkCheckPod(namespace, script string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      namegen.AppendRandomString("network-check"),
+			Namespace: namespace,
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Name:    "network-check",
+				Image:   busyboxImage,
+				Command: []string{"sh", "-c", script},
+			}},
+			NodeSelector:  map[string]string{corev1.LabelOSStable: "linux"},
+			RestartPolicy: corev1.RestartPolicyNever,
+		},
+	}
+}
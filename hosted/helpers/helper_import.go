@@ -0,0 +1,90 @@
+package helpers
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/epinio/epinio/acceptance/helpers/proc"
+	"github.com/pkg/errors"
+	"github.com/rancher/norman/clientbase"
+	"github.com/rancher/rancher/tests/framework/clients/rancher"
+	management "github.com/rancher/rancher/tests/framework/clients/rancher/generated/management/v3"
+	kwait "k8s.io/apimachinery/pkg/util/wait"
+)
+
+// GenericImportedDriver is the driver of clusters registered through the generic import path
+const GenericImportedDriver = "imported"
+
+// CreateGenericImportedCluster creates a plain imported cluster, i.e. one registered by applying the Rancher agent manifest to an existing cluster
+// instead of through the operator of a hosted provider
+func CreateGenericImportedCluster(client *rancher.Client, displayName string) (*management.Cluster, error) {
+	cluster := &management.Cluster{
+		Name:   displayName,
+		Labels: map[string]string{},
+	}
+
+	return client.Management.Cluster.Create(cluster)
+}
+
+// WaitForClusterRegistrationToken waits until Rancher has generated the registration manifest of the cluster, and returns its token
+func WaitForClusterRegistrationToken(client *rancher.Client, clusterID string) (*management.ClusterRegistrationToken, error) {
+	var token *management.ClusterRegistrationToken
+	err := kwait.Poll(5*time.Second, 5*time.Minute, func() (bool, error) {
+		var err error
+		token, err = GetClusterRegistrationToken(client, clusterID)
+		if err != nil {
+			return false, nil
+		}
+		return token.ManifestURL != "", nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "no registration manifest generated for cluster "+clusterID)
+	}
+	return token, nil
+}
+
+// RegisterExistingCluster applies the registration manifest of the cluster to the existing cluster reachable through kubeconfigPath;
+// the manifest is applied over the agent of a previous registration, if any, and the apply is retried while a previous cattle-system namespace is still terminating
+func RegisterExistingCluster(client *rancher.Client, clusterID, kubeconfigPath string) error {
+	token, err := WaitForClusterRegistrationToken(client, clusterID)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Applying registration manifest ...")
+	var out string
+	err = kwait.Poll(10*time.Second, 5*time.Minute, func() (bool, error) {
+		// the manifest is fetched with curl --insecure, as in the insecure command shown by Rancher, so that it works with a self-signed certificate
+		out, err = proc.RunW("bash", "-c", fmt.Sprintf("curl --insecure -sfL %s | kubectl --kubeconfig %s apply -f -", token.ManifestURL, kubeconfigPath))
+		if err != nil {
+			fmt.Println("Failed to apply registration manifest, retrying: ", out)
+			return false, nil
+		}
+		return true, nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "Failed to apply registration manifest: "+out)
+	}
+
+	fmt.Println("Applied registration manifest for cluster: ", clusterID)
+
+	return nil
+}
+
+// WaitUntilClusterIsDeleted waits until the cluster has been removed from Rancher
+func WaitUntilClusterIsDeleted(client *rancher.Client, clusterID string) error {
+	err := kwait.Poll(10*time.Second, Timeout, func() (bool, error) {
+		_, err := client.Management.Cluster.ByID(clusterID)
+		if err != nil {
+			if clientbase.IsNotFound(errors.Cause(err)) {
+				return true, nil
+			}
+			return false, nil
+		}
+		return false, nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "cluster "+clusterID+" was not deleted")
+	}
+	return nil
+}
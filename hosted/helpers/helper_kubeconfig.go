@@ -0,0 +1,160 @@
+package helpers
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rancher/rancher/tests/framework/clients/rancher"
+	namegen "github.com/rancher/rancher/tests/framework/pkg/namegenerator"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kwait "k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// GetDownstreamClientset returns a clientset for the downstream cluster built from the kubeconfig generated by Rancher's generateKubeconfig action;
+// requests go through the Rancher server's cluster proxy, but bypass the Rancher API and Steve
+func GetDownstreamClientset(client *rancher.Client, clusterID string) (*kubernetes.Clientset, error) {
+	cluster, err := client.Management.Cluster.ByID(clusterID)
+	if err != nil {
+		return nil, err
+	}
+	kubeconfig, err := client.Management.Cluster.ActionGenerateKubeconfig(cluster)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to generate kubeconfig")
+	}
+	restConfig, err := clientcmd.RESTConfigFromKubeConfig([]byte(kubeconfig.Config))
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to parse kubeconfig")
+	}
+	return kubernetes.NewForConfig(restConfig)
+}
+
+// CheckServerVersion checks the API server reports the same minor version as kubernetesVersion, for e.g. 1.27 or 1.27.3-gke.100
+func CheckServerVersion(clientset *kubernetes.Clientset, kubernetesVersion string) error {
+	serverVersion, err := clientset.Discovery().ServerVersion()
+	if err != nil {
+		return err
+	}
+	return compareMinorVersion(serverVersion.GitVersion, kubernetesVersion, "API server")
+}
+
+// CheckKubeletVersions checks every node's kubelet reports the same minor version as kubernetesVersion
+func CheckKubeletVersions(clientset *kubernetes.Clientset, kubernetesVersion string) error {
+	nodes, err := clientset.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	if len(nodes.Items) == 0 {
+		return errors.New("no nodes found")
+	}
+	for _, node := range nodes.Items {
+		if err = compareMinorVersion(node.Status.NodeInfo.KubeletVersion, kubernetesVersion, "kubelet of node "+node.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// VerifyClusterThroughKubeconfig checks the downstream cluster through the kubeconfig generated by Rancher: the API server and kubelets run
+// kubernetesVersion, kube-system is healthy and the cluster DNS resolves services
+func VerifyClusterThroughKubeconfig(client *rancher.Client, clusterID, kubernetesVersion string) error {
+	clientset, err := GetDownstreamClientset(client, clusterID)
+	if err != nil {
+		return err
+	}
+	if err = CheckServerVersion(clientset, kubernetesVersion); err != nil {
+		return err
+	}
+	if err = CheckKubeletVersions(clientset, kubernetesVersion); err != nil {
+		return err
+	}
+	if err = WaitForKubeSystemHealth(clientset); err != nil {
+		return err
+	}
+	return CheckDNSResolution(clientset)
+}
+
+// WaitForKubeSystemHealth waits until every deployment of kube-system is available and every pod of kube-system is either succeeded or running and ready;
+// system pods are still being rolled out for a while after the cluster is reported active
+func WaitForKubeSystemHealth(clientset *kubernetes.Clientset) error {
+	var lastErr error
+	err := kwait.Poll(10*time.Second, 10*time.Minute, func() (bool, error) {
+		lastErr = checkKubeSystemHealth(clientset)
+		return lastErr == nil, nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "kube-system is not healthy: %v", lastErr)
+	}
+	return nil
+}
+
+// checkKubeSystemHealth checks every deployment of kube-system is available and every pod of kube-system is either succeeded or running and ready
+func checkKubeSystemHealth(clientset *kubernetes.Clientset) error {
+	deployments, err := clientset.AppsV1().Deployments(metav1.NamespaceSystem).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, deployment := range deployments.Items {
+		if deployment.Spec.Replicas != nil && deployment.Status.AvailableReplicas < *deployment.Spec.Replicas {
+			return errors.Errorf("deployment %s has %d/%d available replicas", deployment.Name, deployment.Status.AvailableReplicas, *deployment.Spec.Replicas)
+		}
+	}
+
+	pods, err := clientset.CoreV1().Pods(metav1.NamespaceSystem).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, pod := range pods.Items {
+		if pod.Status.Phase == corev1.PodSucceeded {
+			continue
+		}
+		if pod.Status.Phase != corev1.PodRunning {
+			return errors.Errorf("pod %s is %s", pod.Name, pod.Status.Phase)
+		}
+		for _, containerStatus := range pod.Status.ContainerStatuses {
+			if !containerStatus.Ready {
+				return errors.Errorf("container %s of pod %s is not ready", containerStatus.Name, pod.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// CheckDNSResolution runs a pod in the default namespace that resolves the kubernetes service through the cluster DNS, and deletes it afterwards
+func CheckDNSResolution(clientset *kubernetes.Clientset) error {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      namegen.AppendRandomString("dns-check"),
+			Namespace: metav1.NamespaceDefault,
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Name:    "dns-check",
+				Image:   busyboxImage,
+				Command: []string{"nslookup", "kubernetes.default.svc.cluster.local"},
+			}},
+			NodeSelector:  map[string]string{corev1.LabelOSStable: "linux"},
+			RestartPolicy: corev1.RestartPolicyNever,
+		},
+	}
+	_, err := RunPodToCompletion(clientset, pod)
+	if err != nil {
+		return errors.Wrap(err, "failed to resolve the kubernetes service")
+	}
+	return nil
+}
+
+// compareMinorVersion returns an error if actualVersion and expectedVersion have a different major or minor version
+func compareMinorVersion(actualVersion, expectedVersion, component string) error {
+	offset, err := MinorVersionOffset(expectedVersion, actualVersion)
+	if err != nil {
+		return errors.Wrap(err, component+" version "+actualVersion+" does not match "+expectedVersion)
+	}
+	if offset != 0 {
+		return errors.Errorf("%s version %s does not match %s", component, actualVersion, expectedVersion)
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rancher/rancher/pkg/api/scheme"
+	"github.com/rancher/rancher/tests/framework/clients/rancher"
+	kubeingresses "github.com/rancher/rancher/tests/framework/extensions/kubeapi/ingresses"
+	kubeservices "github.com/rancher/rancher/tests/framework/extensions/kubeapi/services"
+	namegen "github.com/rancher/rancher/tests/framework/pkg/namegenerator"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	kwait "k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	loadBalancerImage = "nginx:1.25"
+	// LoadBalancerTimeout is how long to wait for the cloud to provision a load balancer and for it to route to the pods
+	LoadBalancerTimeout = 15 * time.Minute
+)
+
+// VerifyLoadBalancerService deploys nginx behind a Service of type LoadBalancer in the downstream cluster, waits for the cloud to assign the
+// Service an external address and checks nginx responds on it; the resources are deleted once done, see verifyLoadBalancer
+func VerifyLoadBalancerService(client *rancher.Client, clusterID string) error {
+	return verifyLoadBalancer(client, clusterID, false)
+}
+
+// VerifyLoadBalancerServiceAndIngress does the same as VerifyLoadBalancerService, and also checks an Ingress routing to the Service responds on
+// its own address; it requires an ingress controller, which only GKE ships by default
+func VerifyLoadBalancerServiceAndIngress(client *rancher.Client, clusterID string) error {
+	return verifyLoadBalancer(client, clusterID, true)
+}
+
+// verifyLoadBalancer creates the resources through a client on a child session of the client's session, which is cleaned up once done,
+// so that the cloud load balancers are removed before the cluster is; they are otherwise cleaned up along with the client's session
+func verifyLoadBalancer(client *rancher.Client, clusterID string, withIngress bool) error {
+	lbSession := client.Session.NewSession()
+	defer lbSession.Cleanup()
+	lbClient, err := client.WithSession(lbSession)
+	if err != nil {
+		return err
+	}
+
+	name := namegen.AppendRandomString("highlander-lb")
+	namespace := metav1.NamespaceDefault
+	deployment, err := CreateDeploymentOnNodes(lbClient, clusterID, namespace, name, loadBalancerImage, map[string]string{corev1.LabelOSStable: "linux"})
+	if err != nil {
+		return errors.Wrap(err, "Failed to create deployment "+name)
+	}
+	_, err = WaitForDeploymentToBeAvailable(lbClient, clusterID, namespace, name)
+	if err != nil {
+		return errors.Wrap(err, "deployment "+name+" is not available")
+	}
+
+	_, err = kubeservices.CreateService(lbClient, clusterID, name, namespace, corev1.ServiceSpec{
+		Type:     corev1.ServiceTypeLoadBalancer,
+		Selector: deployment.Spec.Selector.MatchLabels,
+		Ports:    []corev1.ServicePort{{Name: "http", Port: 80, TargetPort: intstr.FromInt(80), Protocol: corev1.ProtocolTCP}},
+	})
+	if err != nil {
+		return errors.Wrap(err, "Failed to create service "+name)
+	}
+	fmt.Println("Waiting for an external address for service", name, "...")
+	address, err := WaitForServiceLoadBalancerAddress(lbClient, clusterID, namespace, name)
+	if err != nil {
+		return err
+	}
+	fmt.Println("Service", name, "is exposed on", address)
+	err = WaitForHTTPResponse(address)
+	if err != nil {
+		return errors.Wrap(err, "service "+name+" does not respond")
+	}
+
+	if !withIngress {
+		return nil
+	}
+
+	pathType := networkingv1.PathTypePrefix
+	_, err = kubeingresses.CreateIngress(lbClient, clusterID, name, namespace, &networkingv1.IngressSpec{
+		Rules: []networkingv1.IngressRule{{
+			IngressRuleValue: networkingv1.IngressRuleValue{
+				HTTP: &networkingv1.HTTPIngressRuleValue{
+					Paths: []networkingv1.HTTPIngressPath{{
+						Path:     "/",
+						PathType: &pathType,
+						Backend: networkingv1.IngressBackend{
+							Service: &networkingv1.IngressServiceBackend{Name: name, Port: networkingv1.ServiceBackendPort{Number: 80}},
+						},
+					}},
+				},
+			},
+		}},
+	})
+	if err != nil {
+		return errors.Wrap(err, "Failed to create ingress "+name)
+	}
+	fmt.Println("Waiting for an external address for ingress", name, "...")
+	address, err = WaitForIngressAddress(lbClient, clusterID, namespace, name)
+	if err != nil {
+		return err
+	}
+	fmt.Println("Ingress", name, "is exposed on", address)
+	err = WaitForHTTPResponse(address)
+	if err != nil {
+		return errors.Wrap(err, "ingress "+name+" does not route to service "+name)
+	}
+	return nil
+}
+
+// WaitForServiceLoadBalancerAddress waits until the cloud has assigned an external IP or hostname to the Service of type LoadBalancer, and returns it
+func WaitForServiceLoadBalancerAddress(client *rancher.Client, clusterID, namespace, serviceName string) (string, error) {
+	dynamicClient, err := client.GetDownStreamClusterClient(clusterID)
+	if err != nil {
+		return "", err
+	}
+	var address string
+	err = kwait.Poll(10*time.Second, LoadBalancerTimeout, func() (bool, error) {
+		unstructuredService, err := dynamicClient.Resource(kubeservices.ServiceGroupVersionResource).Namespace(namespace).Get(context.TODO(), serviceName, metav1.GetOptions{})
+		if err != nil {
+			return false, nil
+		}
+		service := &corev1.Service{}
+		err = scheme.Scheme.Convert(unstructuredService, service, unstructuredService.GroupVersionKind())
+		if err != nil {
+			return false, err
+		}
+		for _, ingress := range service.Status.LoadBalancer.Ingress {
+			address = loadBalancerAddress(ingress.IP, ingress.Hostname)
+			if address != "" {
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "no external address assigned to service "+serviceName)
+	}
+	return address, nil
+}
+
+// WaitForIngressAddress waits until the ingress controller has assigned an external IP or hostname to the Ingress, and returns it
+func WaitForIngressAddress(client *rancher.Client, clusterID, namespace, ingressName string) (string, error) {
+	dynamicClient, err := client.GetDownStreamClusterClient(clusterID)
+	if err != nil {
+		return "", err
+	}
+	var address string
+	err = kwait.Poll(10*time.Second, LoadBalancerTimeout, func() (bool, error) {
+		unstructuredIngress, err := dynamicClient.Resource(kubeingresses.IngressesGroupVersionResource).Namespace(namespace).Get(context.TODO(), ingressName, metav1.GetOptions{})
+		if err != nil {
+			return false, nil
+		}
+		ingress := &networkingv1.Ingress{}
+		err = scheme.Scheme.Convert(unstructuredIngress, ingress, unstructuredIngress.GroupVersionKind())
+		if err != nil {
+			return false, err
+		}
+		for _, lbIngress := range ingress.Status.LoadBalancer.Ingress {
+			address = loadBalancerAddress(lbIngress.IP, lbIngress.Hostname)
+			if address != "" {
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "no external address assigned to ingress "+ingressName)
+	}
+	return address, nil
+}
+
+// WaitForHTTPResponse waits until a GET request to http://address/ returns 200; the address may not resolve or route at first,
+// for e.g. the DNS name of an AWS load balancer takes a few minutes to propagate
+func WaitForHTTPResponse(address string) error {
+	httpClient := &http.Client{Timeout: 10 * time.Second}
+	var lastErr error
+	err := kwait.Poll(10*time.Second, LoadBalancerTimeout, func() (bool, error) {
+		resp, err := httpClient.Get("http://" + address + "/")
+		if err != nil {
+			lastErr = err
+			return false, nil
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			lastErr = errors.Errorf("GET http://%s/ returned %s", address, resp.Status)
+			return false, nil
+		}
+		return true, nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "no successful response from %s, last error: %v", address, lastErr)
+	}
+	return nil
+}
+
+// loadBalancerAddress returns ip if set, and hostname otherwise; AWS load balancers only have a hostname
+func loadBalancerAddress(ip, hostname string) string {
+	if ip != "" {
+		return ip
+	}
+	return hostname
+}
@@ -0,0 +1,217 @@
+package helpers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rancher/norman/types"
+	"github.com/rancher/rancher/tests/framework/clients/rancher"
+	management "github.com/rancher/rancher/tests/framework/clients/rancher/generated/management/v3"
+	"github.com/rancher/rancher/tests/framework/extensions/kubeapi/nodes"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kwait "k8s.io/apimachinery/pkg/util/wait"
+)
+
+// ListDownstreamNodes lists the Node objects of the downstream cluster through the Rancher proxy;
+// labelSelector can be used to restrict the list to a single nodepool, for e.g. "agentpool=nodepool1"
+func ListDownstreamNodes(client *rancher.Client, clusterID, labelSelector string) ([]corev1.Node, error) {
+	return nodes.GetNodes(client, clusterID, metav1.ListOptions{LabelSelector: labelSelector})
+}
+
+// ListKubeletVersionsByPool lists the downstream nodes and groups their kubelet versions by the value of their poolLabel, for e.g. agentpool
+func ListKubeletVersionsByPool(client *rancher.Client, clusterID, poolLabel string) (map[string][]string, error) {
+	nodeList, err := ListDownstreamNodes(client, clusterID, "")
+	if err != nil {
+		return nil, err
+	}
+	versionsByPool := map[string][]string{}
+	for _, node := range nodeList {
+		pool := node.Labels[poolLabel]
+		versionsByPool[pool] = append(versionsByPool[pool], node.Status.NodeInfo.KubeletVersion)
+	}
+	return versionsByPool, nil
+}
+
+// WaitForNodePoolsKubeletVersion waits until every node of every pool reports a kubelet version matching version;
+// on timeout, the error lists the kubelet versions of each pool
+func WaitForNodePoolsKubeletVersion(client *rancher.Client, clusterID, poolLabel, version string) error {
+	var versionsByPool map[string][]string
+	err := kwait.Poll(10*time.Second, Timeout, func() (bool, error) {
+		var err error
+		versionsByPool, err = ListKubeletVersionsByPool(client, clusterID, poolLabel)
+		if err != nil || len(versionsByPool) == 0 {
+			return false, nil
+		}
+		for _, versions := range versionsByPool {
+			for _, kubeletVersion := range versions {
+				if !versionMatches(kubeletVersion, version) {
+					return false, nil
+				}
+			}
+		}
+		return true, nil
+	})
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("kubelet versions by pool %v do not match %s", versionsByPool, version))
+	}
+	return nil
+}
+
+// WaitForClusterGitVersion waits until Rancher reports a cluster.Version.GitVersion matching version, and returns the updated cluster
+func WaitForClusterGitVersion(client *rancher.Client, clusterID, version string) (*management.Cluster, error) {
+	var cluster *management.Cluster
+	err := kwait.Poll(10*time.Second, Timeout, func() (bool, error) {
+		var err error
+		cluster, err = client.Management.Cluster.ByID(clusterID)
+		if err != nil {
+			return false, nil
+		}
+		return cluster.Version != nil && versionMatches(cluster.Version.GitVersion, version), nil
+	})
+	if err != nil {
+		gitVersion := ""
+		if cluster != nil && cluster.Version != nil {
+			gitVersion = cluster.Version.GitVersion
+		}
+		return nil, errors.Wrap(err, fmt.Sprintf("cluster version %s does not match %s", gitVersion, version))
+	}
+	return cluster, nil
+}
+
+// versionMatches returns true if the Kubernetes actualVersion, for e.g. v1.27.3-eks-a5565ad, is the expectedVersion or a patch of it;
+// expectedVersion is the version format used by the provider, for e.g. 1.27.3, 1.27 or 1.27.3-gke.100
+func versionMatches(actualVersion, expectedVersion string) bool {
+	actualVersion = strings.TrimPrefix(actualVersion, "v")
+	if actualVersion == expectedVersion {
+		return true
+	}
+	for _, separator := range []string{".", "-", "+"} {
+		if strings.HasPrefix(actualVersion, expectedVersion+separator) {
+			return true
+		}
+	}
+	return false
+}
+
+// poolNodes is the state of the nodes of a single pool
+type poolNodes struct {
+	ready    []string
+	notReady []string
+	draining []string
+}
+
+// WaitForNodeCountPerPool waits until the number of ready nodes of each pool equals expectedCounts, as reported by Rancher's management nodes
+// and, if checkDownstream is true, by the downstream Node list; pools are identified by the value of their poolLabel, and nodes of pools missing from expectedCounts are expected to be gone.
+// On timeout, the error contains a per-pool diff that lists the nodes stuck in NotReady or being drained
+func WaitForNodeCountPerPool(client *rancher.Client, clusterID, poolLabel string, expectedCounts map[string]int64, checkDownstream bool) error {
+	var diff string
+	err := kwait.Poll(10*time.Second, Timeout, func() (bool, error) {
+		managementPools, err := listManagementNodesByPool(client, clusterID, poolLabel)
+		if err != nil {
+			return false, nil
+		}
+		diff = nodeCountDiff("management", managementPools, expectedCounts)
+		if checkDownstream {
+			downstreamPools, err := listDownstreamNodesByPool(client, clusterID, poolLabel)
+			if err != nil {
+				return false, nil
+			}
+			diff += nodeCountDiff("downstream", downstreamPools, expectedCounts)
+		}
+		return diff == "", nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "node count did not converge:\n"+diff)
+	}
+	return nil
+}
+
+// listManagementNodesByPool groups Rancher's management nodes of the cluster by the value of their poolLabel
+func listManagementNodesByPool(client *rancher.Client, clusterID, poolLabel string) (map[string]*poolNodes, error) {
+	nodeList, err := client.Management.Node.ListAll(&types.ListOpts{
+		Filters: map[string]interface{}{
+			"clusterId": clusterID,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pools := map[string]*poolNodes{}
+	for _, node := range nodeList.Data {
+		ready := false
+		for _, condition := range node.Conditions {
+			if condition.Type == string(corev1.NodeReady) {
+				ready = condition.Status == string(corev1.ConditionTrue)
+			}
+		}
+		addNodeToPool(pools, node.Labels[poolLabel], node.NodeName, ready, node.Unschedulable || node.State == "draining")
+	}
+	return pools, nil
+}
+
+// listDownstreamNodesByPool groups the downstream nodes by the value of their poolLabel
+func listDownstreamNodesByPool(client *rancher.Client, clusterID, poolLabel string) (map[string]*poolNodes, error) {
+	nodeList, err := ListDownstreamNodes(client, clusterID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	pools := map[string]*poolNodes{}
+	for _, node := range nodeList {
+		ready := false
+		for _, condition := range node.Status.Conditions {
+			if condition.Type == corev1.NodeReady {
+				ready = condition.Status == corev1.ConditionTrue
+			}
+		}
+		addNodeToPool(pools, node.Labels[poolLabel], node.Name, ready, node.Spec.Unschedulable)
+	}
+	return pools, nil
+}
+
+// addNodeToPool adds the node to the ready, notReady or draining list of its pool; cordoned nodes count as draining even if they are ready
+func addNodeToPool(pools map[string]*poolNodes, pool, nodeName string, ready, unschedulable bool) {
+	if pools[pool] == nil {
+		pools[pool] = &poolNodes{}
+	}
+	switch {
+	case unschedulable:
+		pools[pool].draining = append(pools[pool].draining, nodeName)
+	case !ready:
+		pools[pool].notReady = append(pools[pool].notReady, nodeName)
+	default:
+		pools[pool].ready = append(pools[pool].ready, nodeName)
+	}
+}
+
+// nodeCountDiff returns a line for every pool whose nodes do not match expectedCounts, or an empty string if all of them match
+func nodeCountDiff(source string, pools map[string]*poolNodes, expectedCounts map[string]int64) string {
+	poolNames := []string{}
+	for pool := range expectedCounts {
+		poolNames = append(poolNames, pool)
+	}
+	for pool := range pools {
+		if _, ok := expectedCounts[pool]; !ok {
+			poolNames = append(poolNames, pool)
+		}
+	}
+	sort.Strings(poolNames)
+
+	var diff string
+	for _, pool := range poolNames {
+		nodes := pools[pool]
+		if nodes == nil {
+			nodes = &poolNodes{}
+		}
+		if int64(len(nodes.ready)) == expectedCounts[pool] && len(nodes.notReady) == 0 && len(nodes.draining) == 0 {
+			continue
+		}
+		diff += fmt.Sprintf("%s pool %q: expected %d ready nodes, got %d; not ready: %v; draining: %v\n", source, pool, expectedCounts[pool], len(nodes.ready), nodes.notReady, nodes.draining)
+	}
+	return diff
+}
@@ -0,0 +1,50 @@
+package helpers
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rancher/rancher/tests/framework/clients/rancher"
+	management "github.com/rancher/rancher/tests/framework/clients/rancher/generated/management/v3"
+	kubenamespaces "github.com/rancher/rancher/tests/framework/extensions/kubeapi/namespaces"
+	corev1 "k8s.io/api/core/v1"
+	kwait "k8s.io/apimachinery/pkg/util/wait"
+)
+
+// CreateProject creates a Rancher project in the cluster and waits for it to be active
+func CreateProject(client *rancher.Client, clusterID, projectName string) (*management.Project, error) {
+	project, err := client.Management.Project.Create(&management.Project{
+		ClusterID: clusterID,
+		Name:      projectName,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create project "+projectName)
+	}
+
+	projectID := project.ID
+	err = kwait.Poll(5*time.Second, 5*time.Minute, func() (bool, error) {
+		currentProject, err := client.Management.Project.ByID(projectID)
+		if err != nil {
+			return false, nil
+		}
+		project = currentProject
+		return project.State == "active", nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "project "+projectName+" is not active")
+	}
+	return project, nil
+}
+
+// CreateProjectAndNamespace creates a Rancher project in the cluster and a namespace of the same name in it
+func CreateProjectAndNamespace(client *rancher.Client, clusterID, name string) (*management.Project, *corev1.Namespace, error) {
+	project, err := CreateProject(client, clusterID, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	namespace, err := kubenamespaces.CreateNamespace(client, name, "", nil, nil, project)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Failed to create namespace "+name)
+	}
+	return project, namespace, nil
+}
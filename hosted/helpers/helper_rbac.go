@@ -0,0 +1,107 @@
+package helpers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/pkg/errors"
+	"github.com/rancher/norman/clientbase"
+	"github.com/rancher/norman/types"
+	"github.com/rancher/rancher/tests/framework/clients/rancher"
+	management "github.com/rancher/rancher/tests/framework/clients/rancher/generated/management/v3"
+	"github.com/rancher/rancher/tests/framework/extensions/users"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// StandardUserGlobalRole is the global role of the users that can only access the clusters and projects they are members of
+	StandardUserGlobalRole = "user"
+	// UserBaseGlobalRole is the global role allowing a user to log in and manage their own resources, such as cloud credentials
+	UserBaseGlobalRole = "user-base"
+	// ClustersCreateGlobalRole is the global role allowing a user to create clusters, of which they become the owner
+	ClustersCreateGlobalRole = "clusters-create"
+	// ClusterOwnerRole is the role template granting full control over a cluster, including editing and deleting it
+	ClusterOwnerRole = "cluster-owner"
+	// ProjectMemberRole is the role template granting the management of the workloads in the namespaces of a project
+	ProjectMemberRole = "project-member"
+)
+
+// CreateUser creates a user with the global roles, and returns it along with a client logged in as the user;
+// the user is deleted when the client's session is cleaned up
+func CreateUser(client *rancher.Client, globalRoles ...string) (*management.User, *rancher.Client, error) {
+	user, err := users.CreateUserWithRole(client, users.UserConfig(), globalRoles...)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Failed to create user")
+	}
+	userClient, err := client.AsUser(user)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Failed to log in as user "+user.Username)
+	}
+	return user, userClient, nil
+}
+
+// CreateStandardUser creates a user with the standard user global role, see CreateUser
+func CreateStandardUser(client *rancher.Client) (*management.User, *rancher.Client, error) {
+	return CreateUser(client, StandardUserGlobalRole)
+}
+
+// CanListPods returns true if the client is allowed to list the pods of the namespace in the downstream cluster, and false if it is forbidden
+func CanListPods(client *rancher.Client, clusterID, namespace string) (bool, error) {
+	dynamicClient, err := client.GetDownStreamClusterClient(clusterID)
+	if err != nil {
+		return false, err
+	}
+	_, err = dynamicClient.Resource(PodGroupVersionResource).Namespace(namespace).List(context.TODO(), metav1.ListOptions{})
+	if IsForbidden(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// CanSeeCloudCredential returns true if the client can get the cloud credential or finds it when listing cloud credentials
+func CanSeeCloudCredential(client *rancher.Client, cloudCredentialID string) (bool, error) {
+	_, err := client.Management.CloudCredential.ByID(cloudCredentialID)
+	if err == nil {
+		return true, nil
+	}
+	if !IsForbidden(err) && !clientbase.IsNotFound(errors.Cause(err)) {
+		return false, err
+	}
+
+	cloudCredentials, err := client.Management.CloudCredential.ListAll(nil)
+	if err != nil {
+		return false, err
+	}
+	for _, cloudCredential := range cloudCredentials.Data {
+		if cloudCredential.ID == cloudCredentialID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// IsClusterOwner returns true if the user is bound to the cluster owner role of the cluster
+func IsClusterOwner(client *rancher.Client, clusterID string, user *management.User) (bool, error) {
+	bindings, err := client.Management.ClusterRoleTemplateBinding.ListAll(&types.ListOpts{
+		Filters: map[string]interface{}{"clusterId": clusterID, "userId": user.ID},
+	})
+	if err != nil {
+		return false, err
+	}
+	for _, binding := range bindings.Data {
+		if binding.RoleTemplateID == ClusterOwnerRole {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// IsForbidden returns true if err is a Rancher or Kubernetes API error caused by the lack of permissions
+func IsForbidden(err error) bool {
+	apiError, ok := errors.Cause(err).(*clientbase.APIError)
+	return (ok && apiError.StatusCode == http.StatusForbidden) || apierrors.IsForbidden(errors.Cause(err))
+}
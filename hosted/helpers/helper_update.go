@@ -0,0 +1,109 @@
+package helpers
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	"github.com/rancher/rancher/tests/framework/clients/rancher"
+	management "github.com/rancher/rancher/tests/framework/clients/rancher/generated/management/v3"
+)
+
+// UpdateCluster fetches the latest state of the cluster, applies mutateFunc to a deep copy of it and updates the cluster with
+// the resulting provider config and labels; the update is aborted if mutateFunc returns an error.
+// The cluster objects held by the caller are never modified.
+func UpdateCluster(client *rancher.Client, clusterID string, mutateFunc func(*management.Cluster) error) (*management.Cluster, error) {
+	cluster, err := client.Management.Cluster.ByID(clusterID)
+	if err != nil {
+		return nil, err
+	}
+	clusterCopy, err := deepCopyCluster(cluster)
+	if err != nil {
+		return nil, err
+	}
+	if err = mutateFunc(clusterCopy); err != nil {
+		return nil, err
+	}
+
+	upgradedCluster := new(management.Cluster)
+	upgradedCluster.Name = cluster.Name
+	upgradedCluster.AKSConfig = clusterCopy.AKSConfig
+	upgradedCluster.EKSConfig = clusterCopy.EKSConfig
+	upgradedCluster.GKEConfig = clusterCopy.GKEConfig
+	upgradedCluster.Labels = clusterCopy.Labels
+
+	updatedCluster, err := client.Management.Cluster.Update(cluster, upgradedCluster)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to update cluster "+clusterID)
+	}
+	return updatedCluster, nil
+}
+
+// deepCopyCluster returns a copy of the cluster that shares no maps, slices or pointers with it
+func deepCopyCluster(cluster *management.Cluster) (*management.Cluster, error) {
+	data, err := json.Marshal(cluster)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to copy cluster "+cluster.Name)
+	}
+	clusterCopy := new(management.Cluster)
+	if err = json.Unmarshal(data, clusterCopy); err != nil {
+		return nil, errors.Wrap(err, "failed to copy cluster "+cluster.Name)
+	}
+	return clusterCopy, nil
+}
+
+// RestoreClusterConfig updates the cluster with the provider config of original, for e.g. to revert a rejected change
+func RestoreClusterConfig(client *rancher.Client, original *management.Cluster) (*management.Cluster, error) {
+	originalCopy, err := deepCopyCluster(original)
+	if err != nil {
+		return nil, err
+	}
+	return UpdateCluster(client, original.ID, func(upgradedCluster *management.Cluster) error {
+		upgradedCluster.AKSConfig = originalCopy.AKSConfig
+		upgradedCluster.EKSConfig = originalCopy.EKSConfig
+		upgradedCluster.GKEConfig = originalCopy.GKEConfig
+		return nil
+	})
+}
+
+// VerifyChangeIsRejected applies change to the cluster and verifies that it is rejected by Rancher or the operator;
+// the config of the cluster is then restored, and the cluster is expected to become active with its config and upstream spec unchanged
+func VerifyChangeIsRejected(client *rancher.Client, cluster *management.Cluster, change func() (*management.Cluster, error)) error {
+	_, err := VerifyChangeIsRejectedWithMessage(client, cluster, change)
+	return err
+}
+
+// VerifyChangeIsRejectedWithMessage is VerifyChangeIsRejected, but also returns the rejection message, so that callers can check why the change was rejected
+func VerifyChangeIsRejectedWithMessage(client *rancher.Client, cluster *management.Cluster, change func() (*management.Cluster, error)) (string, error) {
+	snapshot, err := TakeClusterSnapshot(client, cluster.ID)
+	if err != nil {
+		return "", err
+	}
+
+	currentCluster, err := client.Management.Cluster.ByID(cluster.ID)
+	if err != nil {
+		return "", err
+	}
+	previousMessage := clusterErrorMessage(currentCluster)
+
+	_, updateErr := change()
+	message, err := WaitForChangeToBeRejected(client, cluster.ID, previousMessage, updateErr)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err = RestoreClusterConfig(client, cluster); err != nil {
+		return message, err
+	}
+	if _, err = WaitUntilClusterIsActive(client, cluster.ID); err != nil {
+		return message, err
+	}
+
+	diff, err := DiffClusterSince(client, cluster.ID, snapshot)
+	if err != nil {
+		return message, err
+	}
+	if len(diff.Config) > 0 || len(diff.UpstreamSpec) > 0 {
+		return message, errors.Errorf("cluster %s changed after the rejected change was reverted; config: %v, upstream spec: %v", cluster.Name, diff.Config, diff.UpstreamSpec)
+	}
+	return message, nil
+}
@@ -0,0 +1,129 @@
+package helpers
+
+import (
+	"github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rancher/rancher/tests/framework/clients/rancher"
+	management "github.com/rancher/rancher/tests/framework/clients/rancher/generated/management/v3"
+)
+
+// VersionSkewProvider holds the provider specific steps of the version skew specs
+type VersionSkewProvider struct {
+	// Cloud is the provider passed to CommonBeforeSuite, for e.g. aks
+	Cloud string
+	// ListVersions returns the Kubernetes versions a cluster can be created with
+	ListVersions func(ctx Context) ([]string, error)
+	// CreateCluster creates a cluster with its control plane and nodes on k8sVersion, without waiting for it to be ready
+	CreateCluster func(ctx Context, k8sVersion string) (*management.Cluster, error)
+	// DeleteCluster deletes the cluster
+	DeleteCluster func(cluster *management.Cluster, client *rancher.Client) error
+	// UpgradeControlPlane updates the Kubernetes version of the control plane only
+	UpgradeControlPlane func(cluster *management.Cluster, upgradeToVersion *string, client *rancher.Client) (*management.Cluster, error)
+	// UpgradeNodes updates the Kubernetes version of every nodepool
+	UpgradeNodes func(cluster *management.Cluster, upgradeToVersion *string, client *rancher.Client) (*management.Cluster, error)
+	// NewerNodesRejection matches the message rejecting nodes newer than the control plane
+	NewerNodesRejection string
+	// NodeSkewRejection matches the message rejecting a control plane upgrade that leaves the nodes too many minor versions behind
+	NodeSkewRejection string
+	// SkippedMinorRejection matches the message rejecting a control plane upgrade that skips a minor version
+	SkippedMinorRejection string
+}
+
+// DescribeVersionSkew registers the specs checking that the provider rejects Kubernetes version combinations
+// that break the version skew policy; it is meant to be assigned to a package level variable of a test suite
+func DescribeVersionSkew(provider VersionSkewProvider) bool {
+	return ginkgo.Describe("P1VersionSkew", func() {
+		var (
+			ctx      Context
+			cluster  *management.Cluster
+			versions []string
+		)
+		ginkgo.BeforeEach(func() {
+			ctx = CommonBeforeSuite(provider.Cloud)
+			cluster = nil
+			var err error
+			versions, err = provider.ListVersions(ctx)
+			Expect(err).To(BeNil())
+			versions, err = SortVersions(versions)
+			Expect(err).To(BeNil())
+			Expect(versions).ToNot(BeEmpty())
+		})
+		ginkgo.AfterEach(func() {
+			if cluster == nil {
+				return
+			}
+			err := provider.DeleteCluster(cluster, ctx.RancherClient)
+			Expect(err).To(BeNil())
+		})
+
+		// createCluster creates a cluster with the control plane and nodes on k8sVersion
+		createCluster := func(k8sVersion string) {
+			var err error
+			cluster, err = provider.CreateCluster(ctx, k8sVersion)
+			Expect(err).To(BeNil())
+			cluster, err = WaitUntilClusterIsReady(cluster, ctx.RancherClient)
+			Expect(err).To(BeNil())
+		}
+
+		// verifyVersionChangeIsRejected applies change and checks it is rejected with a message matching rejectionPattern
+		verifyVersionChangeIsRejected := func(rejectionPattern string, change func() (*management.Cluster, error)) {
+			Expect(rejectionPattern).ToNot(BeEmpty())
+			message, err := VerifyChangeIsRejectedWithMessage(ctx.RancherClient, cluster, change)
+			Expect(err).To(BeNil())
+			Expect(message).To(MatchRegexp(rejectionPattern))
+		}
+
+		ginkgo.It("should reject nodes newer than the control plane", func() {
+			controlPlaneVersion := versions[0]
+			nodeVersion, err := VersionWithMinorOffset(versions, controlPlaneVersion, 1)
+			if err != nil {
+				ginkgo.Skip("no newer minor version available: " + err.Error())
+			}
+			createCluster(controlPlaneVersion)
+
+			verifyVersionChangeIsRejected(provider.NewerNodesRejection, func() (*management.Cluster, error) {
+				return provider.UpgradeNodes(cluster, &nodeVersion, ctx.RancherClient)
+			})
+		})
+
+		ginkgo.It("should reject control plane upgrades leaving the nodes more minor versions behind than allowed", func() {
+			nodeVersion, upgradePath, err := NodeSkewUpgradePath(versions)
+			if err != nil {
+				ginkgo.Skip("not enough minor versions available: " + err.Error())
+			}
+			createCluster(nodeVersion)
+
+			// the nodes stay on nodeVersion while the control plane is upgraded up to the maximum allowed skew
+			for _, version := range upgradePath[:len(upgradePath)-1] {
+				version := version
+				ginkgo.By("upgrading the control plane to "+version, func() {
+					var err error
+					cluster, err = provider.UpgradeControlPlane(cluster, &version, ctx.RancherClient)
+					Expect(err).To(BeNil())
+					cluster, err = WaitUntilClusterIsUpgraded(ctx.RancherClient, cluster)
+					Expect(err).To(BeNil())
+				})
+			}
+
+			skewedVersion := upgradePath[len(upgradePath)-1]
+			ginkgo.By("upgrading the control plane to "+skewedVersion, func() {
+				verifyVersionChangeIsRejected(provider.NodeSkewRejection, func() (*management.Cluster, error) {
+					return provider.UpgradeControlPlane(cluster, &skewedVersion, ctx.RancherClient)
+				})
+			})
+		})
+
+		ginkgo.It("should reject control plane upgrades that skip a minor version", func() {
+			controlPlaneVersion := versions[0]
+			upgradeToVersion, err := VersionWithMinorOffset(versions, controlPlaneVersion, 2)
+			if err != nil {
+				ginkgo.Skip("no version two minor versions newer available: " + err.Error())
+			}
+			createCluster(controlPlaneVersion)
+
+			verifyVersionChangeIsRejected(provider.SkippedMinorRejection, func() (*management.Cluster, error) {
+				return provider.UpgradeControlPlane(cluster, &upgradeToVersion, ctx.RancherClient)
+			})
+		})
+	})
+}
@@ -0,0 +1,110 @@
+package helpers
+
+import (
+	"sort"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/pkg/errors"
+)
+
+// MaxNodeVersionSkew returns the number of minor versions the nodes may be older than a control plane of controlPlaneVersion,
+// as per the Kubernetes version skew policy: three since 1.28 and two before; the hosted providers allow at most as many
+func MaxNodeVersionSkew(controlPlaneVersion string) (int64, error) {
+	version, err := semver.NewVersion(controlPlaneVersion)
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to parse version "+controlPlaneVersion)
+	}
+	if version.Major() == 1 && version.Minor() < 28 {
+		return 2, nil
+	}
+	return 3, nil
+}
+
+// SortVersions returns a copy of versions sorted from the oldest to the newest
+func SortVersions(versions []string) ([]string, error) {
+	semVersions := make([]*semver.Version, 0, len(versions))
+	for _, version := range versions {
+		semVersion, err := semver.NewVersion(version)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to parse version "+version)
+		}
+		semVersions = append(semVersions, semVersion)
+	}
+	sort.Sort(semver.Collection(semVersions))
+
+	sortedVersions := make([]string, 0, len(versions))
+	for _, semVersion := range semVersions {
+		sortedVersions = append(sortedVersions, semVersion.Original())
+	}
+	return sortedVersions, nil
+}
+
+// SingleVariantVersions returns the first version of each minor in versions, which are expected to be sorted by minor
+func SingleVariantVersions(versions []string) []string {
+	var singleVersionList []string
+	var oldMinor uint64
+	for _, version := range versions {
+		semVersion := semver.MustParse(version)
+		if currentMinor := semVersion.Minor(); oldMinor != currentMinor {
+			singleVersionList = append(singleVersionList, version)
+			oldMinor = currentMinor
+		}
+	}
+	return singleVersionList
+}
+
+// MinorVersionOffset returns the number of minor versions from fromVersion to toVersion, which is negative if toVersion is older;
+// both versions must have the same major version
+func MinorVersionOffset(fromVersion, toVersion string) (int64, error) {
+	from, err := semver.NewVersion(fromVersion)
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to parse version "+fromVersion)
+	}
+	to, err := semver.NewVersion(toVersion)
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to parse version "+toVersion)
+	}
+	if from.Major() != to.Major() {
+		return 0, errors.Errorf("versions %s and %s have different major versions", fromVersion, toVersion)
+	}
+	return int64(to.Minor()) - int64(from.Minor()), nil
+}
+
+// VersionWithMinorOffset returns the first version of versions that is offset minor versions away from baseVersion,
+// for e.g. an offset of 2 from 1.26.6 returns the first 1.28 version
+func VersionWithMinorOffset(versions []string, baseVersion string, offset int64) (string, error) {
+	for _, version := range versions {
+		versionOffset, err := MinorVersionOffset(baseVersion, version)
+		if err != nil {
+			continue
+		}
+		if versionOffset == offset {
+			return version, nil
+		}
+	}
+	return "", errors.Errorf("no version %d minor versions away from %s in %v", offset, baseVersion, versions)
+}
+
+// NodeSkewUpgradePath returns the oldest version of versions from which the control plane can be upgraded one minor version at a time
+// until it is one minor version further ahead of the nodes than the skew policy allows, along with the version of each of these upgrades;
+// versions are expected to be sorted from the oldest to the newest
+func NodeSkewUpgradePath(versions []string) (string, []string, error) {
+	for _, baseVersion := range versions {
+		var upgradePath []string
+		for offset := int64(1); ; offset++ {
+			version, err := VersionWithMinorOffset(versions, baseVersion, offset)
+			if err != nil {
+				break
+			}
+			upgradePath = append(upgradePath, version)
+			maxSkew, err := MaxNodeVersionSkew(version)
+			if err != nil {
+				return "", nil, err
+			}
+			if offset > maxSkew {
+				return baseVersion, upgradePath, nil
+			}
+		}
+	}
+	return "", nil, errors.Errorf("no version in %v is followed by enough minor versions to exceed the node version skew", versions)
+}
@@ -0,0 +1,181 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	namegen "github.com/rancher/rancher/tests/framework/pkg/namegenerator"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// DefaultStorageClassAnnotation marks the StorageClass used by claims that do not set one
+	DefaultStorageClassAnnotation = "storageclass.kubernetes.io/is-default-class"
+
+	volumeMountPath = "/data"
+)
+
+// VolumeData is a persistent volume claim holding a data file and its checksum, written by WriteVolumeData
+type VolumeData struct {
+	Namespace    string
+	ClaimName    string
+	StorageClass string
+	// Writer is the node of the pod that wrote the data
+	Writer VolumeNode
+}
+
+// VolumeNode identifies the node a volume pod ran on; the boot ID tells apart the nodes reimaged under the same name,
+// for e.g. by an AKS upgrade
+type VolumeNode struct {
+	Name   string
+	BootID string
+}
+
+// GetDefaultStorageClass returns the name of the default StorageClass of the cluster, for e.g. managed-csi on AKS, gp2 on EKS and standard-rwo on GKE
+func GetDefaultStorageClass(clientset *kubernetes.Clientset) (string, error) {
+	storageClasses, err := clientset.StorageV1().StorageClasses().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return "", err
+	}
+	for _, storageClass := range storageClasses.Items {
+		if storageClass.Annotations[DefaultStorageClassAnnotation] == "true" {
+			return storageClass.Name, nil
+		}
+	}
+	return "", errors.New("no default StorageClass found")
+}
+
+// HasCSIDriver returns true if the CSI driver is registered in the cluster, for e.g. ebs.csi.aws.com
+func HasCSIDriver(clientset *kubernetes.Clientset, driverName string) (bool, error) {
+	drivers, err := clientset.StorageV1().CSIDrivers().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return false, err
+	}
+	for _, driver := range drivers.Items {
+		if driver.Name == driverName {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// WriteVolumeData creates a claim with the default StorageClass of the cluster and writes a random data file and its checksum to it from a pod;
+// the pod is removed once done so that the volume can be attached to the pod of VerifyVolumeData, wherever it is scheduled
+func WriteVolumeData(clientset *kubernetes.Clientset) (*VolumeData, error) {
+	storageClass, err := GetDefaultStorageClass(clientset)
+	if err != nil {
+		return nil, err
+	}
+
+	claim := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      namegen.AppendRandomString("volume-data"),
+			Namespace: metav1.NamespaceDefault,
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")},
+			},
+		},
+	}
+	claim, err = clientset.CoreV1().PersistentVolumeClaims(claim.Namespace).Create(context.TODO(), claim, metav1.CreateOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create persistent volume claim")
+	}
+	volumeData := &VolumeData{Namespace: claim.Namespace, ClaimName: claim.Name, StorageClass: storageClass}
+
+	fmt.Println("Writing data to persistent volume claim", claim.Name, "of StorageClass", storageClass, "...")
+	script := fmt.Sprintf("dd if=/dev/urandom of=%[1]s/data bs=1M count=10 && cd %[1]s && md5sum data > data.md5 && sync", volumeMountPath)
+	pod, err := runVolumePod(clientset, volumeData, "volume-writer", script)
+	if err == nil {
+		volumeData.Writer, err = getVolumeNode(clientset, pod)
+	}
+	if err != nil {
+		if deleteErr := DeleteVolumeData(clientset, volumeData); deleteErr != nil {
+			fmt.Println(deleteErr)
+		}
+		return nil, errors.Wrap(err, "Failed to write data to persistent volume claim "+claim.Name)
+	}
+	fmt.Println("Wrote data to persistent volume claim", claim.Name, "from node", volumeData.Writer.Name)
+
+	return volumeData, nil
+}
+
+// VerifyVolumeData checks the data written by WriteVolumeData against its checksum from a new pod, and returns the node it was scheduled on
+func VerifyVolumeData(clientset *kubernetes.Clientset, volumeData *VolumeData) (VolumeNode, error) {
+	fmt.Println("Verifying data of persistent volume claim", volumeData.ClaimName, "...")
+	script := fmt.Sprintf("cd %s && md5sum -c data.md5", volumeMountPath)
+	pod, err := runVolumePod(clientset, volumeData, "volume-reader", script)
+	if err != nil {
+		return VolumeNode{}, errors.Wrap(err, "Failed to verify data of persistent volume claim "+volumeData.ClaimName)
+	}
+	reader, err := getVolumeNode(clientset, pod)
+	if err != nil {
+		return VolumeNode{}, err
+	}
+	fmt.Println("Verified data of persistent volume claim", volumeData.ClaimName, "from node", reader.Name)
+	return reader, nil
+}
+
+// IsVolumeNodeGone returns true if the node was removed or rebooted since the volume pod ran on it
+func IsVolumeNodeGone(clientset *kubernetes.Clientset, volumeNode VolumeNode) (bool, error) {
+	node, err := clientset.CoreV1().Nodes().Get(context.TODO(), volumeNode.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, errors.Wrap(err, "Failed to get node "+volumeNode.Name)
+	}
+	return node.Status.NodeInfo.BootID != volumeNode.BootID, nil
+}
+
+// DeleteVolumeData deletes the claim created by WriteVolumeData, along with its volume
+func DeleteVolumeData(clientset *kubernetes.Clientset, volumeData *VolumeData) error {
+	err := clientset.CoreV1().PersistentVolumeClaims(volumeData.Namespace).Delete(context.TODO(), volumeData.ClaimName, metav1.DeleteOptions{})
+	if err != nil {
+		return errors.Wrap(err, "Failed to delete persistent volume claim "+volumeData.ClaimName)
+	}
+	return nil
+}
+
+// getVolumeNode returns the node the pod ran on
+func getVolumeNode(clientset *kubernetes.Clientset, pod *corev1.Pod) (VolumeNode, error) {
+	node, err := clientset.CoreV1().Nodes().Get(context.TODO(), pod.Spec.NodeName, metav1.GetOptions{})
+	if err != nil {
+		return VolumeNode{}, errors.Wrap(err, "Failed to get node "+pod.Spec.NodeName)
+	}
+	return VolumeNode{Name: node.Name, BootID: node.Status.NodeInfo.BootID}, nil
+}
+
+// runVolumePod runs script in a pod mounting the claim of volumeData until it completes, see RunPodToCompletion
+func runVolumePod(clientset *kubernetes.Clientset, volumeData *VolumeData, name, script string) (*corev1.Pod, error) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      namegen.AppendRandomString(name),
+			Namespace: volumeData.Namespace,
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Name:         name,
+				Image:        busyboxImage,
+				Command:      []string{"sh", "-c", script},
+				VolumeMounts: []corev1.VolumeMount{{Name: "data", MountPath: volumeMountPath}},
+			}},
+			Volumes: []corev1.Volume{{
+				Name: "data",
+				VolumeSource: corev1.VolumeSource{
+					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: volumeData.ClaimName},
+				},
+			}},
+			NodeSelector:  map[string]string{corev1.LabelOSStable: "linux"},
+			RestartPolicy: corev1.RestartPolicyNever,
+		},
+	}
+	return RunPodToCompletion(clientset, pod)
+}
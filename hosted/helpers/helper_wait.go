@@ -0,0 +1,180 @@
+package helpers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rancher/norman/clientbase"
+	"github.com/rancher/rancher/tests/framework/clients/rancher"
+	management "github.com/rancher/rancher/tests/framework/clients/rancher/generated/management/v3"
+	kwait "k8s.io/apimachinery/pkg/util/wait"
+)
+
+// TransitionGracePeriod is the time within which a cluster is expected to start updating after its spec has been changed
+var TransitionGracePeriod = 5 * time.Minute
+
+// WaitUntilClusterIsUpgraded waits until the cluster is observed transitioning into an updating state within TransitionGracePeriod, then waits until it is active again,
+// and returns the cluster as fetched from the server, so that assertions run against its actual state, including the upstream spec, instead of the Update response.
+// updatedCluster is the cluster returned by the update; a transition is also observed if the Updated condition of the cluster changed since then,
+// in case the update completed before it was polled.
+// It fails if no transition is observed within TransitionGracePeriod, so that a spec change that was never reconciled does not pass as a successful update.
+// On failure, the last observed cluster is returned along with the error, so that callers keep an object to clean up
+func WaitUntilClusterIsUpgraded(client *rancher.Client, updatedCluster *management.Cluster) (*management.Cluster, error) {
+	if updatedCluster == nil {
+		return nil, errors.New("no cluster to wait for")
+	}
+	clusterID := updatedCluster.ID
+	previousUpdateTime := conditionLastUpdateTime(updatedCluster, "Updated")
+
+	cluster := updatedCluster
+	err := kwait.Poll(2*time.Second, TransitionGracePeriod, func() (bool, error) {
+		currentCluster, err := client.Management.Cluster.ByID(clusterID)
+		if err != nil {
+			return false, nil
+		}
+		cluster = currentCluster
+		return isClusterUpdating(cluster) || conditionLastUpdateTime(cluster, "Updated") != previousUpdateTime, nil
+	})
+	if err != nil {
+		return cluster, errors.Wrap(err, fmt.Sprintf("cluster %s did not start updating within %s; %s", clusterID, TransitionGracePeriod, clusterStatus(cluster)))
+	}
+	fmt.Println("Cluster started updating: ", clusterID)
+
+	err = kwait.Poll(10*time.Second, Timeout, func() (bool, error) {
+		currentCluster, err := client.Management.Cluster.ByID(clusterID)
+		if err != nil {
+			return false, nil
+		}
+		cluster = currentCluster
+		// an update that failed does not recover on its own, so do not wait for the whole Timeout
+		if message := clusterErrorMessage(cluster); message != "" {
+			return false, errors.New("cluster reports an error: " + message)
+		}
+		return cluster.State == "active" && !isClusterUpdating(cluster), nil
+	})
+	if err != nil {
+		return cluster, errors.Wrap(err, fmt.Sprintf("cluster %s did not become active after updating; %s", clusterID, clusterStatus(cluster)))
+	}
+	return cluster, nil
+}
+
+// isClusterUpdating returns true if the cluster is in an updating state
+func isClusterUpdating(cluster *management.Cluster) bool {
+	switch cluster.State {
+	case "updating", "upgrading", "provisioning":
+		return true
+	}
+	return cluster.Transitioning == "yes"
+}
+
+// conditionLastUpdateTime returns the last update time of the condition of the given type, or an empty string if the cluster has no such condition
+func conditionLastUpdateTime(cluster *management.Cluster, conditionType string) string {
+	for _, condition := range cluster.Conditions {
+		if condition.Type == conditionType {
+			return condition.LastUpdateTime
+		}
+	}
+	return ""
+}
+
+// WaitForUpstreamSpec waits until the upstream spec of the cluster has been populated by its operator, and returns the cluster
+func WaitForUpstreamSpec(client *rancher.Client, clusterID string) (*management.Cluster, error) {
+	var cluster *management.Cluster
+	err := kwait.Poll(10*time.Second, Timeout, func() (bool, error) {
+		var err error
+		cluster, err = client.Management.Cluster.ByID(clusterID)
+		if err != nil {
+			return false, nil
+		}
+		_, upstreamSpec := providerSpecs(cluster)
+		return upstreamSpec != nil, nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("upstream spec of cluster %s was not populated", clusterID))
+	}
+	return cluster, nil
+}
+
+// WaitForChangeToBeRejected checks that a change to the cluster was rejected and returns the rejection message;
+// the change is rejected either by Rancher, in which case updateErr is the client error returned by the update, see IsRejectionError,
+// or by the operator, which reports an error on the cluster within TransitionGracePeriod. previousMessage is the error reported
+// on the cluster before the change, if any, so that it is not mistaken for the rejection
+func WaitForChangeToBeRejected(client *rancher.Client, clusterID, previousMessage string, updateErr error) (string, error) {
+	if updateErr != nil {
+		if !IsRejectionError(updateErr) {
+			return "", errors.Wrap(updateErr, fmt.Sprintf("change to cluster %s failed without being rejected", clusterID))
+		}
+		fmt.Println("Change rejected by Rancher: ", updateErr.Error())
+		return updateErr.Error(), nil
+	}
+
+	var message string
+	err := kwait.Poll(5*time.Second, TransitionGracePeriod, func() (bool, error) {
+		cluster, err := client.Management.Cluster.ByID(clusterID)
+		if err != nil {
+			return false, nil
+		}
+		message = clusterErrorMessage(cluster)
+		return message != "" && message != previousMessage, nil
+	})
+	if err != nil {
+		return "", errors.Wrap(err, fmt.Sprintf("change to cluster %s was not rejected within %s", clusterID, TransitionGracePeriod))
+	}
+	fmt.Println("Change rejected by the operator: ", message)
+	return message, nil
+}
+
+// IsRejectionError returns true if err is an API error by which Rancher refuses a change, i.e. a bad request, a forbidden change or an invalid body
+func IsRejectionError(err error) bool {
+	apiError, ok := errors.Cause(err).(*clientbase.APIError)
+	if !ok {
+		return false
+	}
+	switch apiError.StatusCode {
+	case http.StatusBadRequest, http.StatusForbidden, http.StatusUnprocessableEntity:
+		return true
+	}
+	return false
+}
+
+// WaitUntilClusterIsActive waits until the cluster is active and reports no error, whether or not it goes through an update first;
+// unlike WaitUntilClusterIsUpgraded it keeps waiting on a reported error, which is expected to clear once a rejected change has been reverted.
+// On failure, the last observed cluster is returned along with the error
+func WaitUntilClusterIsActive(client *rancher.Client, clusterID string) (*management.Cluster, error) {
+	var cluster *management.Cluster
+	err := kwait.Poll(10*time.Second, Timeout, func() (bool, error) {
+		currentCluster, err := client.Management.Cluster.ByID(clusterID)
+		if err != nil {
+			return false, nil
+		}
+		cluster = currentCluster
+		return cluster.State == "active" && !isClusterUpdating(cluster) && clusterErrorMessage(cluster) == "", nil
+	})
+	if err != nil {
+		return cluster, errors.Wrap(err, fmt.Sprintf("cluster %s did not become active; %s", clusterID, clusterStatus(cluster)))
+	}
+	return cluster, nil
+}
+
+// clusterStatus describes the state of the cluster for error messages; cluster is nil if it could not be fetched
+func clusterStatus(cluster *management.Cluster) string {
+	if cluster == nil {
+		return "the cluster could not be fetched"
+	}
+	return fmt.Sprintf("state: %s, message: %s", cluster.State, cluster.TransitioningMessage)
+}
+
+// clusterErrorMessage returns the error reported on the cluster by Rancher or its operator, or an empty string if there is none
+func clusterErrorMessage(cluster *management.Cluster) string {
+	if cluster.Transitioning == "error" {
+		return cluster.TransitioningMessage
+	}
+	for _, condition := range cluster.Conditions {
+		if (condition.Type == "Updated" || condition.Type == "Provisioned") && condition.Status == "False" && condition.Message != "" {
+			return condition.Message
+		}
+	}
+	return ""
+}
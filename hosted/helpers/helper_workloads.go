@@ -0,0 +1,114 @@
+package helpers
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rancher/rancher/pkg/api/scheme"
+	"github.com/rancher/rancher/tests/framework/clients/rancher"
+	"github.com/rancher/rancher/tests/framework/extensions/kubeapi/workloads/deployments"
+	appv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	kwait "k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+// busyboxImage is the image of the short-lived pods running shell checks in the downstream cluster
+const busyboxImage = "busybox:1.36"
+
+// PodGroupVersionResource is the Group Version Resource for accessing pods in a cluster using the dynamic client
+var PodGroupVersionResource = schema.GroupVersionResource{
+	Group:    "",
+	Version:  "v1",
+	Resource: "pods",
+}
+
+// CreateDeploymentOnNodes creates a single container deployment of image whose pods are scheduled only on the nodes matching nodeSelector
+func CreateDeploymentOnNodes(client *rancher.Client, clusterID, namespace, deploymentName, image string, nodeSelector map[string]string) (*appv1.Deployment, error) {
+	podTemplate := corev1.PodTemplateSpec{
+		Spec: corev1.PodSpec{
+			Containers:   []corev1.Container{{Name: deploymentName, Image: image, ImagePullPolicy: corev1.PullIfNotPresent}},
+			NodeSelector: nodeSelector,
+		},
+	}
+	return deployments.CreateDeployment(client, clusterID, deploymentName, namespace, podTemplate)
+}
+
+// WaitForDeploymentToBeAvailable waits until all the replicas of the deployment are available
+func WaitForDeploymentToBeAvailable(client *rancher.Client, clusterID, namespace, deploymentName string) (*appv1.Deployment, error) {
+	var deployment *appv1.Deployment
+	err := kwait.Poll(10*time.Second, Timeout, func() (bool, error) {
+		deploymentList, err := deployments.ListDeployments(client, clusterID, namespace, metav1.ListOptions{FieldSelector: "metadata.name=" + deploymentName})
+		if err != nil || len(deploymentList.Items) == 0 {
+			return false, nil
+		}
+		deployment = &deploymentList.Items[0]
+		return deployment.Spec.Replicas != nil && *deployment.Spec.Replicas == deployment.Status.AvailableReplicas, nil
+	})
+	return deployment, err
+}
+
+// ListDownstreamPods lists the Pod objects of the namespace in the downstream cluster through the Rancher proxy
+func ListDownstreamPods(client *rancher.Client, clusterID, namespace, labelSelector string) ([]corev1.Pod, error) {
+	dynamicClient, err := client.GetDownStreamClusterClient(clusterID)
+	if err != nil {
+		return nil, err
+	}
+	unstructuredPods, err := dynamicClient.Resource(PodGroupVersionResource).Namespace(namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: labelSelector})
+	if err != nil {
+		return nil, err
+	}
+
+	var podList []corev1.Pod
+	for _, unstructuredPod := range unstructuredPods.Items {
+		pod := corev1.Pod{}
+		err = scheme.Scheme.Convert(&unstructuredPod, &pod, unstructuredPod.GroupVersionKind())
+		if err != nil {
+			return nil, err
+		}
+		podList = append(podList, pod)
+	}
+	return podList, nil
+}
+
+// RunPodToCompletion creates the pod in the downstream cluster, waits until it completes and deletes it, then returns it as last observed;
+// the deletion is waited for, so that the volumes of the pod are released on return. It returns an error with the pod logs if the pod fails
+func RunPodToCompletion(clientset *kubernetes.Clientset, pod *corev1.Pod) (*corev1.Pod, error) {
+	pods := clientset.CoreV1().Pods(pod.Namespace)
+	pod, err := pods.Create(context.TODO(), pod, metav1.CreateOptions{})
+	if err != nil {
+		return nil, err
+	}
+	podName := pod.Name
+
+	defer func() {
+		_ = pods.Delete(context.TODO(), podName, metav1.DeleteOptions{})
+		_ = kwait.Poll(5*time.Second, 5*time.Minute, func() (bool, error) {
+			_, err := pods.Get(context.TODO(), podName, metav1.GetOptions{})
+			return err != nil, nil
+		})
+	}()
+
+	err = kwait.Poll(5*time.Second, 10*time.Minute, func() (bool, error) {
+		currentPod, err := pods.Get(context.TODO(), podName, metav1.GetOptions{})
+		if err != nil {
+			return false, nil
+		}
+		pod = currentPod
+		switch pod.Status.Phase {
+		case corev1.PodSucceeded:
+			return true, nil
+		case corev1.PodFailed:
+			logs, _ := pods.GetLogs(podName, &corev1.PodLogOptions{}).DoRaw(context.TODO())
+			return false, errors.Errorf("pod %s failed: %s", podName, logs)
+		}
+		return false, nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "pod %s did not complete, it is %s", podName, pod.Status.Phase)
+	}
+	return pod, nil
+}